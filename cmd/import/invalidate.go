@@ -0,0 +1,77 @@
+package main
+
+import (
+	"WB_Service/intrenal/importer"
+	model "WB_Service/intrenal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// invalidatingStore после каждой сохранённой пачки просит запущенные экземпляры сервиса
+// убрать её заказы из кеша (POST /admin/cache/invalidate). Ошибка оповещения прерывает импорт:
+// иначе сервис продолжит отдавать данные до импорта, а resume_line покажет, откуда повторить.
+type invalidatingStore struct {
+	store  importer.Store
+	urls   []string
+	client *http.Client
+}
+
+func newInvalidatingStore(store importer.Store, urls string) *invalidatingStore {
+	s := &invalidatingStore{
+		store:  store,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, u := range strings.Split(urls, ",") {
+		if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" {
+			s.urls = append(s.urls, u+"/admin/cache/invalidate")
+		}
+	}
+	return s
+}
+
+func (s *invalidatingStore) ImportOrders(ctx context.Context, orders []*model.Order) ([]string, error) {
+	stale, err := s.store.ImportOrders(ctx, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]string, len(orders))
+	for i, order := range orders {
+		uids[i] = order.OrderUUID
+	}
+	body, err := json.Marshal(map[string][]string{"order_uids": uids})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cache invalidation: %w", err)
+	}
+
+	for _, u := range s.urls {
+		if err := s.invalidate(ctx, u, body); err != nil {
+			return nil, err
+		}
+	}
+	return stale, nil
+}
+
+func (s *invalidatingStore) invalidate(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create cache invalidation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate cache at %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to invalidate cache at %s: %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"WB_Service/intrenal/config"
+	"WB_Service/intrenal/db"
+	"WB_Service/intrenal/importer"
+	"WB_Service/intrenal/lib/sl"
+	"WB_Service/intrenal/logger"
+	model "WB_Service/intrenal/models"
+	"WB_Service/intrenal/validation"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Импорт исторических заказов из NDJSON/CSV напрямую в Postgres.
+// Заказы, которые не прошли бы обычное сохранение по kafka.out_of_order (в БД новее файла
+// или не совпала version), не перезаписываются и попадают в отчёт как stale.
+// Запущенный сервис держит заказы в кеше: чтобы он не отдавал данные до импорта, передайте
+// в -invalidate адреса всех его экземпляров — после каждой пачки они уберут её заказы из кеша.
+// Без -invalidate старые версии уйдут из кеша только по TTL или вытеснению.
+//
+//	go run ./cmd/import -config config/config.yaml -file orders.ndjson -invalidate http://localhost:8080
+func main() {
+	os.Exit(run())
+}
+
+// run возвращает код выхода; вынесен из main, чтобы defer отработали до os.Exit
+func run() int {
+	// флаги объявляются до config.MustLoad: он вызывает flag.Parse
+	file := flag.String("file", "-", "input file, - for stdin")
+	format := flag.String("format", "", "ndjson | csv, by default inferred from file extension")
+	dryRun := flag.Bool("dry-run", false, "parse and validate only, do not write to database")
+	startLine := flag.Int("start-line", 0, "resume from this line of the file")
+	chunkSize := flag.Int("chunk", 500, "orders per transaction")
+	reportPath := flag.String("report", "", "write JSON report to file instead of stdout")
+	invalidate := flag.String("invalidate", "", "comma-separated base URLs of running service instances whose cache to invalidate")
+
+	cfg := config.MustLoad()
+	log := logger.SetupLogger(cfg.Env)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *format == "" {
+		*format = formatByExt(*file)
+	}
+
+	var in io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Error("failed to open input file", sl.Err(err))
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if err := cfg.Kafka.OutOfOrder.Validate(); err != nil {
+		log.Error("invalid kafka config", sl.Err(err))
+		return 1
+	}
+
+	orderValidator, err := validation.New(cfg.Validation)
+	if err != nil {
+		log.Error("invalid validation config", sl.Err(err))
+		return 1
+	}
+
+	var store importer.Store
+	if !*dryRun {
+		dbService, err := db.NewPostgres(ctx, cfg.Postgres, log)
+		if err != nil {
+			log.Error("failed to connect to database", sl.Err(err))
+			return 1
+		}
+		defer dbService.Close()
+		store = policyStore{db: dbService, policy: cfg.Kafka.OutOfOrder}
+
+		if *invalidate != "" {
+			store = newInvalidatingStore(store, *invalidate)
+		} else {
+			log.Warn("-invalidate is not set: running service instances may serve cached pre-import orders until they expire")
+		}
+	}
+
+	report, importErr := importer.New(store, orderValidator, log).Import(ctx, in, importer.Options{
+		Format:    *format,
+		DryRun:    *dryRun,
+		StartLine: *startLine,
+		ChunkSize: *chunkSize,
+	})
+	if report != nil {
+		if err := writeReport(*reportPath, report); err != nil {
+			log.Error("failed to write report", sl.Err(err))
+		}
+	}
+
+	if importErr != nil {
+		log.Error("import aborted", sl.Err(importErr))
+		if report != nil && report.ResumeLine > 0 {
+			log.Info("to continue run again with", "start-line", report.ResumeLine)
+		}
+		return 1
+	}
+	log.Info("import finished",
+		"read", report.Read, "valid", report.Valid, "imported", report.Imported, "stale", report.Stale, "failed", report.Failed)
+	return 0
+}
+
+// policyStore сохраняет пачки с той же политикой out_of_order, что и сервис
+type policyStore struct {
+	db     *db.Postgres
+	policy model.OutOfOrderPolicy
+}
+
+func (s policyStore) ImportOrders(ctx context.Context, orders []*model.Order) ([]string, error) {
+	return s.db.ImportOrders(ctx, orders, s.policy)
+}
+
+func formatByExt(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return importer.FormatCSV
+	}
+	return importer.FormatNDJSON
+}
+
+func writeReport(path string, report *importer.Report) error {
+	out := os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
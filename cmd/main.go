@@ -4,12 +4,19 @@ import (
 	cache "WB_Service/intrenal/cache"
 	"WB_Service/intrenal/config"
 	"WB_Service/intrenal/db"
+	"WB_Service/intrenal/health"
 	"WB_Service/intrenal/http/handler"
+	"WB_Service/intrenal/importer"
 	"WB_Service/intrenal/kafka/consumer"
+	"WB_Service/intrenal/kafka/dlq"
+	"WB_Service/intrenal/kafka/outbox"
+	"WB_Service/intrenal/kafka/producer"
 	"WB_Service/intrenal/lib/sl"
+	"WB_Service/intrenal/lifecycle"
 	"WB_Service/intrenal/logger"
-	model "WB_Service/intrenal/models"
+	"WB_Service/intrenal/metrics"
 	"WB_Service/intrenal/service"
+	"WB_Service/intrenal/validation"
 	"errors"
 	"github.com/IBM/sarama"
 
@@ -19,8 +26,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
-	"time"
 )
 
 func main() {
@@ -42,32 +49,107 @@ func main() {
 	}
 	log.Info("connected to database")
 
-	cacheService := cache.NewCache(cfg.TTl)
-	// восстанавливаем кеш при перезапуске
-	_ = cacheService.ReStoreCache(map[string]*model.Order{})
+	cacheService, err := cache.NewCache(cfg.Cache)
+	if err != nil {
+		log.Error("failed to create cache", sl.Err(err))
+		os.Exit(1)
+	}
+
+	if err := cfg.Kafka.OutOfOrder.Validate(); err != nil {
+		log.Error("invalid kafka config", sl.Err(err))
+		os.Exit(1)
+	}
+	orderService := service.NewService(dbService, cacheService, cfg.Kafka.OutOfOrder, log)
+
+	// ключи идемпотентности для POST /publish-order
+	idempotency := service.NewIdempotency(dbService, cfg.Idempotency, log)
+	go idempotency.RunCleanup(ctx)
+
+	// статистика заказов для /stats/orders
+	stats := service.NewStats(dbService, cfg.Stats, log)
 
-	orderService := service.NewService(dbService, cacheService, log)
+	// VALIDATION: одни и те же правила для HTTP и consumer
+	orderValidator, err := validation.New(cfg.Validation)
+	if err != nil {
+		log.Error("invalid validation config", sl.Err(err))
+		os.Exit(1)
+	}
 
 	// инициализация producer
-	producerCfg := sarama.NewConfig()
-	producerCfg.Producer.RequiredAcks = sarama.WaitForAll // ждать подтверждения от всех реплик
-	producerCfg.Producer.Retry.Max = 5                    // до 5 попыток при ошибке
-	producerCfg.Producer.Return.Successes = true
-	syncProducer, err := sarama.NewSyncProducer(cfg.Kafka.Brokers, producerCfg)
+	syncProducer, err := producer.New(cfg.Kafka.Brokers, cfg.Producer)
+	if err != nil {
+		log.Error("failed to start kafka producer", sl.Err(err))
+		os.Exit(1)
+	}
+	syncProducer = metrics.InstrumentProducer(syncProducer)
+
+	// DLQ: публикация из consumer и админка для просмотра/повторной отправки
+	dlqPublisher := dlq.NewPublisher(syncProducer, cfg.Kafka.DLQTopic)
+	kafkaClient, err := sarama.NewClient(cfg.Kafka.Brokers, sarama.NewConfig())
+	if err != nil {
+		log.Error("failed to create kafka client", sl.Err(err))
+		os.Exit(1)
+	}
+	dlqAdmin := dlq.NewAdmin(kafkaClient, syncProducer, cfg.Kafka.DLQTopic, cfg.Kafka.Topic)
+
+	// OUTBOX: события о сохранённых заказах уходят в Kafka из таблицы order_events
+	outboxRelay := outbox.NewRelay(dbService, syncProducer, cfg.Outbox, log)
+	if cfg.Outbox.Enabled {
+		outboxRelay.Start(ctx)
+	}
+
+	// Health: /readyz проверяет все зависимости и окончание прогрева кеша
+	var cacheWarm atomic.Bool
+	checker := health.NewChecker(cfg.HTTPConfig.HealthTimeout)
+	checker.Add("postgres", dbService.Ping)
+	checker.Add("migrations", dbService.CheckMigrations)
+	checker.Add("kafka_producer", health.KafkaTopic(kafkaClient, cfg.Kafka.Topic))
+	checker.Add("kafka_consumer_group", health.KafkaGroup(kafkaClient, cfg.Kafka.GroupID))
+	checker.Add("cache_warmup", health.Flag(&cacheWarm))
 
 	// HTTP Router
 	router := chi.NewRouter()
-	handlers := serv.NewHandler(orderService, syncProducer)
+	handlers := serv.NewHandler(orderService, syncProducer, cfg.Producer.Topic, orderValidator, idempotency, log)
+	dlqHandlers := serv.NewDLQHandler(dlqAdmin)
+	statsHandlers := serv.NewStatsHandler(stats)
+	importHandlers := serv.NewImportHandler(importer.New(orderService, orderValidator, log), log)
 
 	router.Use(middleware.RequestID)
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
+	router.Use(metrics.HTTPMiddleware)
+
+	// Метрики
+	metrics.Registry.MustRegister(
+		metrics.NewCacheCollector(cacheService),
+		metrics.NewPoolCollector(dbService),
+	)
+	router.Handle("/metrics", metrics.Handler())
+
+	// Health
+	router.Get("/healthz", checker.LivenessHandler)
+	router.Get("/readyz", checker.ReadinessHandler)
 
 	// API
 	router.Get("/order/{order_uid}", handlers.GetOrderHandler)
 	router.Get("/orders", handlers.GetOrdersHandler)
+	router.Get("/orders/search", handlers.SearchOrdersHandler)
+	router.With(middleware.Compress(5, "text/csv", "application/x-ndjson")).
+		Get("/orders/export", handlers.ExportHandler)
+	router.Patch("/order/{order_uid}/status", handlers.ChangeStatusHandler)
+	router.Get("/order/{order_uid}/revisions", handlers.RevisionsHandler)
+	router.Get("/order/{order_uid}/revisions/{from}/diff/{to}", handlers.RevisionDiffHandler)
+	router.Post("/orders/import", importHandlers.ImportOrdersHandler)
 	router.Post("/publish-order", handlers.SaveOrderHandler)
 
+	// Analytics
+	router.Get("/stats/orders", statsHandlers.OrderStatsHandler)
+
+	// Admin
+	router.Get("/admin/dlq", dlqHandlers.ListHandler)
+	router.Post("/admin/dlq/{partition}/{offset}/redrive", dlqHandlers.RedriveHandler)
+	router.Post("/admin/cache/invalidate", handlers.InvalidateCacheHandler)
+
 	// Статика (CSS, JS и т.п.)
 	fs := http.FileServer(http.Dir("./static"))
 	router.Handle("/static/*", http.StripPrefix("/static/", fs))
@@ -86,13 +168,12 @@ func main() {
 		IdleTimeout:  cfg.HTTPConfig.IdleTimeout,
 	}
 
-	// Запуск Kafka consumer в отдельной горутине
-	go func() {
-		if err := consumer.StartConsumer(ctx, cfg, orderService); err != nil {
-			log.Error("failed to start consumer", sl.Err(err))
-			cancel()
-		}
-	}()
+	// Запуск Kafka consumer
+	consumerGroup, err := consumer.StartConsumer(ctx, cfg, orderService, orderValidator, dlqPublisher, syncProducer, log)
+	if err != nil {
+		log.Error("failed to start consumer", sl.Err(err))
+		os.Exit(1)
+	}
 
 	// Запуск HTTP сервера в отдельной горутине
 	go func() {
@@ -103,6 +184,17 @@ func main() {
 		}
 	}()
 
+	// прогреваем кеш при перезапуске; до окончания прогрева /readyz отвечает 503
+	go func() {
+		defer cacheWarm.Store(true)
+		if !cfg.Cache.Warmup.Enabled {
+			return
+		}
+		if _, err := orderService.RestoreCache(ctx, cfg.Cache.Warmup); err != nil {
+			log.Warn("cache warm-up finished with error, continuing with partial cache", sl.Err(err))
+		}
+	}()
+
 	// Ожидание сигнала завершения
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
@@ -114,13 +206,22 @@ func main() {
 		log.Info("context cancelled, shutting down...")
 	}
 
-	// Graceful shutdown
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer shutdownCancel()
-
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Error("failed to gracefully shutdown server", sl.Err(err))
-	} else {
-		log.Info("http server stopped gracefully")
+	// Graceful shutdown: порядок важен — сначала перестаём принимать трафик,
+	// затем дорабатываем сообщения, и только потом закрываем producer и пул
+	lc := lifecycle.New(log)
+	lc.AddFunc("readiness", checker.SetShuttingDown)
+	lc.Add("http server", srv.Shutdown)
+	lc.AddFunc("background tasks", cancel)
+	lc.Add("kafka consumer", consumerGroup.Close)
+	lc.Add("outbox relay", outboxRelay.Close)
+	lc.Add("kafka producer", func(context.Context) error { return syncProducer.Close() })
+	lc.Add("kafka client", func(context.Context) error { return kafkaClient.Close() })
+	lc.AddFunc("postgres pool", dbService.Close)
+	lc.AddFunc("cache", cacheService.Close)
+
+	if err := lc.Shutdown(context.Background(), cfg.ShutdownTimeout); err != nil {
+		log.Error("graceful shutdown finished with errors", sl.Err(err))
+		os.Exit(1)
 	}
+	log.Info("service stopped gracefully")
 }
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_items_order_uid;
+
+-- Оставляем по одному товару на заказ, иначе первичный ключ не восстановить
+DELETE FROM items a USING items b WHERE a.order_uid = b.order_uid AND a.id > b.id;
+
+ALTER TABLE items DROP COLUMN IF EXISTS id;
+ALTER TABLE items ADD PRIMARY KEY (order_uid);
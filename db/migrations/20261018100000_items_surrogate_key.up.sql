@@ -0,0 +1,6 @@
+-- Несколько товаров на один заказ: order_uid больше не первичный ключ
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_pkey;
+ALTER TABLE items ALTER COLUMN order_uid SET NOT NULL;
+ALTER TABLE items ADD COLUMN IF NOT EXISTS id BIGSERIAL PRIMARY KEY;
+
+CREATE INDEX IF NOT EXISTS idx_items_order_uid ON items (order_uid);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payment_bank;
+DROP INDEX IF EXISTS idx_payment_currency_provider;
+DROP INDEX IF EXISTS idx_orders_delivery_service;
+DROP INDEX IF EXISTS idx_orders_customer_id;
+DROP INDEX IF EXISTS idx_orders_date_created_uid;
@@ -0,0 +1,6 @@
+-- Индексы для keyset-пагинации и фильтров листинга заказов
+CREATE INDEX IF NOT EXISTS idx_orders_date_created_uid ON orders (date_created, order_uid);
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders (customer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_service ON orders (delivery_service);
+CREATE INDEX IF NOT EXISTS idx_payment_currency_provider ON payment (currency, provider);
+CREATE INDEX IF NOT EXISTS idx_payment_bank ON payment (bank);
@@ -0,0 +1,15 @@
+-- Outbox событий заказа: пишется в одной транзакции с заказом, отправляется в Kafka relay-горутиной
+CREATE TABLE IF NOT EXISTS order_events (
+                        id BIGSERIAL PRIMARY KEY,
+                        order_uid TEXT NOT NULL,
+                        event_type TEXT NOT NULL,
+                        payload JSONB NOT NULL,
+                        created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                        sent_at TIMESTAMPTZ,
+                        attempts INT NOT NULL DEFAULT 0,
+                        last_error TEXT,
+                        next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_events_pending ON order_events (next_attempt_at, id) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_order_events_pending_order ON order_events (order_uid, id) WHERE sent_at IS NULL;
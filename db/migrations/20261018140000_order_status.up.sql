@@ -0,0 +1,13 @@
+-- Статус заказа меняется только через переходы, каждый переход пишется в историю
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'created';
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+                        id BIGSERIAL PRIMARY KEY,
+                        order_uid TEXT NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+                        from_status TEXT NOT NULL,
+                        to_status TEXT NOT NULL,
+                        source TEXT NOT NULL,
+                        changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order ON order_status_history (order_uid, changed_at);
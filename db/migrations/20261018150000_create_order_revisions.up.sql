@@ -0,0 +1,9 @@
+-- Снимки содержимого заказа: новая ревизия пишется, только если данные изменились.
+-- Для заказов, сохранённых до миграции, история начинается со следующего сохранения.
+CREATE TABLE IF NOT EXISTS order_revisions (
+                        order_uid TEXT NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+                        revision INT NOT NULL,
+                        snapshot JSONB NOT NULL,
+                        created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                        PRIMARY KEY (order_uid, revision)
+);
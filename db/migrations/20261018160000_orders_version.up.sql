@@ -0,0 +1,2 @@
+-- Версия заказа для оптимистичных блокировок: растёт при каждом изменении заказа или его статуса
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_items_brand_trgm;
+DROP INDEX IF EXISTS idx_items_nm_id;
+DROP INDEX IF EXISTS idx_delivery_name_trgm;
+DROP INDEX IF EXISTS idx_delivery_email_trgm;
+DROP INDEX IF EXISTS idx_delivery_phone;
+DROP INDEX IF EXISTS idx_orders_track_number;
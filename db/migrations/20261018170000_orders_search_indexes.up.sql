@@ -0,0 +1,10 @@
+-- Индексы для поиска заказов поддержкой: точные ключи — btree,
+-- частичный регистронезависимый поиск (ILIKE '%...%') — триграммы
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_orders_track_number ON orders (track_number);
+CREATE INDEX IF NOT EXISTS idx_delivery_phone ON delivery (phone);
+CREATE INDEX IF NOT EXISTS idx_delivery_email_trgm ON delivery USING gin (email gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_delivery_name_trgm ON delivery USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_items_nm_id ON items (nm_id);
+CREATE INDEX IF NOT EXISTS idx_items_brand_trgm ON items USING gin (brand gin_trgm_ops);
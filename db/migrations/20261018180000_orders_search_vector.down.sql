@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_search_vector;
+ALTER TABLE orders DROP COLUMN IF EXISTS search_vector;
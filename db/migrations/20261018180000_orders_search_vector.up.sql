@@ -0,0 +1,13 @@
+-- Полнотекстовый поиск по товарам (name, brand) и адресу доставки (city, address, region).
+-- Конфигурация словаря выбирается по locale заказа; вектор пересчитывается при каждом сохранении.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+UPDATE orders o SET search_vector =
+    setweight(to_tsvector(c.cfg, coalesce((SELECT string_agg(concat_ws(' ', i.name, i.brand), ' ')
+                                           FROM items i WHERE i.order_uid = o.order_uid), '')), 'A') ||
+    setweight(to_tsvector(c.cfg, concat_ws(' ', d.city, d.address, d.region)), 'B')
+FROM delivery d,
+     LATERAL (SELECT (CASE lower(o.locale) WHEN 'ru' THEN 'russian' WHEN 'en' THEN 'english' ELSE 'simple' END)::regconfig AS cfg) c
+WHERE d.order_uid = o.order_uid;
+
+CREATE INDEX IF NOT EXISTS idx_orders_search_vector ON orders USING gin (search_vector);
@@ -0,0 +1 @@
+ALTER TABLE orders ALTER COLUMN date_created DROP NOT NULL;
@@ -0,0 +1,4 @@
+-- keyset-пагинация сравнивает (date_created, order_uid): строки с NULL выпадали бы со второй страницы.
+-- Старые заказы без даты получают epoch и идут в начале листинга.
+UPDATE orders SET date_created = 'epoch' WHERE date_created IS NULL;
+ALTER TABLE orders ALTER COLUMN date_created SET NOT NULL;
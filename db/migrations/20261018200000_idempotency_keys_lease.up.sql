@@ -0,0 +1,3 @@
+-- Аренда ключа идемпотентности: если запрос оборвался между резервированием и ответом,
+-- после locked_until ключ может занять повторный запрос
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
@@ -0,0 +1 @@
+ALTER TABLE order_events DROP COLUMN IF EXISTS locked_until;
@@ -0,0 +1,3 @@
+-- Аренда события outbox: relay захватывает пачку до locked_until и отправляет её вне транзакции.
+-- Если relay упал во время отправки, после locked_until событие захватит другая реплика.
+ALTER TABLE order_events ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
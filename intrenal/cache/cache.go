@@ -6,59 +6,134 @@ import (
 	"time"
 )
 
+type Config struct {
+	TTL             time.Duration `yaml:"ttl" env-default:"10s"`
+	MaxEntries      int           `yaml:"max_entries" env-default:"100000"`
+	MaxBytes        int64         `yaml:"max_bytes" env-default:"268435456"`
+	Policy          string        `yaml:"policy" env-default:"lru"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"1m"`
+	Warmup          WarmupConfig  `yaml:"warmup"`
+}
+
+// WarmupConfig задаёт, какие заказы подгружать из БД в кеш при старте
+type WarmupConfig struct {
+	Enabled   bool          `yaml:"enabled" env-default:"true"`
+	MaxAge    time.Duration `yaml:"max_age" env-default:"72h"`
+	MaxCount  int           `yaml:"max_count" env-default:"10000"`
+	BatchSize int           `yaml:"batch_size" env-default:"500"`
+	Workers   int           `yaml:"workers" env-default:"4"`
+	Timeout   time.Duration `yaml:"timeout" env-default:"30s"`
+}
+
+type entry struct {
+	order   *model.Order
+	expires time.Time
+	size    int64
+}
+
+// Stats — счётчики обращений и текущий размер кеша
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	Entries     int
+	Bytes       int64
+}
+
 type Cache struct {
-	mu      sync.RWMutex
-	orders  map[string]*model.Order
-	ttl     time.Duration
-	expires map[string]time.Time
+	mu         sync.Mutex
+	entries    map[string]*entry
+	policy     Policy
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	stats      Stats
+
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
-func NewCache(ttl time.Duration) *Cache {
-	return &Cache{
-		orders:  make(map[string]*model.Order),
-		ttl:     ttl,
-		expires: make(map[string]time.Time),
+func NewCache(cfg Config) (*Cache, error) {
+	policy, err := NewPolicy(cfg.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cache{
+		entries:    make(map[string]*entry),
+		policy:     policy,
+		ttl:        cfg.TTL,
+		maxEntries: cfg.MaxEntries,
+		maxBytes:   cfg.MaxBytes,
+		stop:       make(chan struct{}),
+	}
+
+	// фоновая очистка просроченных ключей
+	if cfg.CleanupInterval > 0 {
+		go c.janitor(cfg.CleanupInterval)
 	}
+
+	return c, nil
 }
 
 func (c *Cache) SetOrder(order *model.Order) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	c.orders[order.OrderUUID] = order
-	c.expires[order.OrderUUID] = time.Now().Add(c.ttl)
+	c.set(order, time.Now())
+}
+
+// SetOrderIfNewer кладёт заказ, только если в кеше нет его версии новее или такой же.
+// Нужен, когда заказ прочитан из БД заранее (прогрев) и за это время его могли перезаписать.
+func (c *Cache) SetOrderIfNewer(order *model.Order) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[order.OrderUUID]; ok && e.order.Version >= order.Version {
+		return false
+	}
+	c.set(order, time.Now())
+	return true
 }
 
 func (c *Cache) GetOrder(orderUUID string) (*model.Order, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	order, exists := c.orders[orderUUID]
+	e, exists := c.entries[orderUUID]
 	if !exists {
+		c.stats.Misses++
 		return nil, false
 	}
 
-	if time.Now().After(c.expires[order.OrderUUID]) {
+	if c.expired(e, time.Now()) {
+		c.remove(orderUUID)
+		c.stats.Expirations++
+		c.stats.Misses++
 		return nil, false
 	}
 
-	return order, true
+	c.policy.Touch(orderUUID)
+	c.stats.Hits++
+	return e.order, true
 }
 
 func (c *Cache) GetAll() (map[string]*model.Order, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if len(c.orders) == 0 {
+	if len(c.entries) == 0 {
 		return nil, false
 	}
 
-	result := make(map[string]*model.Order)
+	result := make(map[string]*model.Order, len(c.entries))
 	now := time.Now()
 
-	for uid, order := range c.orders {
-		if now.Before(c.expires[uid]) {
-			result[uid] = order
+	for uid, e := range c.entries {
+		if !c.expired(e, now) {
+			result[uid] = e.order
 		}
 	}
 
@@ -69,14 +144,139 @@ func (c *Cache) GetAll() (map[string]*model.Order, bool) {
 	return result, true
 }
 
+func (c *Cache) Delete(orderUUID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(orderUUID)
+}
+
+// Len возвращает количество записей и их приблизительный размер в байтах
+func (c *Cache) Len() (int, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries), c.bytes
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	stats.Bytes = c.bytes
+	return stats
+}
+
 func (c *Cache) ReStoreCache(orders map[string]*model.Order) map[string]*model.Order {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for uid := range c.entries {
+		c.remove(uid)
+	}
 
-	c.orders = orders
 	now := time.Now()
-	for uid := range c.orders {
-		c.expires[uid] = now.Add(c.ttl)
+	for _, order := range orders {
+		c.set(order, now)
 	}
 	return orders
 }
+
+// Close останавливает фоновую очистку
+func (c *Cache) Close() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+func (c *Cache) set(order *model.Order, now time.Time) {
+	e := &entry{
+		order: order,
+		size:  orderSize(order),
+	}
+	if c.ttl > 0 {
+		e.expires = now.Add(c.ttl)
+	}
+
+	if old, ok := c.entries[order.OrderUUID]; ok {
+		// обновление: число записей не меняется, может вырасти только размер
+		c.entries[order.OrderUUID] = e
+		c.bytes += e.size - old.size
+		c.policy.Touch(order.OrderUUID)
+		c.evict(order.OrderUUID, 0, 0)
+		return
+	}
+
+	// место освобождаем до вставки, иначе политика может выбрать жертвой сам новый ключ
+	// (в LFU у него наименьшая частота) и вытеснение остановится с превышением лимитов
+	c.evict("", 1, e.size)
+
+	c.entries[order.OrderUUID] = e
+	c.bytes += e.size
+	c.policy.Add(order.OrderUUID)
+}
+
+// evict вытесняет записи по политике, пока в кеш не поместятся ещё entries записей и bytes байт.
+// Ключ keep не вытесняется: если жертвой выбран он, превышение уйдёт при следующей вставке.
+// Запись, которая одна больше max_bytes, всё равно сохраняется.
+func (c *Cache) evict(keep string, entries int, bytes int64) {
+	for c.overflow(entries, bytes) {
+		victim, ok := c.policy.Victim()
+		if !ok || victim == keep {
+			return
+		}
+		c.remove(victim)
+		c.stats.Evictions++
+	}
+}
+
+// overflow сообщает, превысит ли кеш лимиты, если добавить entries записей и bytes байт
+func (c *Cache) overflow(entries int, bytes int64) bool {
+	if c.maxEntries > 0 && len(c.entries)+entries > c.maxEntries {
+		return true
+	}
+	return c.maxBytes > 0 && c.bytes+bytes > c.maxBytes
+}
+
+func (c *Cache) remove(orderUUID string) {
+	e, ok := c.entries[orderUUID]
+	if !ok {
+		return
+	}
+	delete(c.entries, orderUUID)
+	c.bytes -= e.size
+	c.policy.Remove(orderUUID)
+}
+
+func (c *Cache) expired(e *entry, now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+func (c *Cache) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.deleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *Cache) deleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for uid, e := range c.entries {
+		if c.expired(e, now) {
+			c.remove(uid)
+			c.stats.Expirations++
+		}
+	}
+}
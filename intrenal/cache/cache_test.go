@@ -0,0 +1,172 @@
+package cache
+
+import (
+	model "WB_Service/intrenal/models"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func newTestCache(t *testing.T, policy string, maxEntries int, maxBytes int64) *Cache {
+	t.Helper()
+
+	c, err := NewCache(Config{Policy: policy, MaxEntries: maxEntries, MaxBytes: maxBytes})
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func keys(c *Cache) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestCacheEviction(t *testing.T) {
+	// op: "set:<uid>" или "get:<uid>"
+	tests := []struct {
+		name   string
+		policy string
+		max    int
+		ops    []string
+		want   []string
+	}{
+		{
+			name:   "lru evicts least recently used",
+			policy: PolicyLRU,
+			max:    2,
+			ops:    []string{"set:a", "set:b", "get:a", "set:c"},
+			want:   []string{"a", "c"},
+		},
+		{
+			name:   "lru update refreshes key",
+			policy: PolicyLRU,
+			max:    2,
+			ops:    []string{"set:a", "set:b", "set:a", "set:c"},
+			want:   []string{"a", "c"},
+		},
+		{
+			name:   "lfu evicts least frequently used",
+			policy: PolicyLFU,
+			max:    2,
+			ops:    []string{"set:a", "get:a", "get:a", "set:b", "get:b", "set:c"},
+			want:   []string{"a", "c"},
+		},
+		{
+			name:   "lfu ties broken by recency",
+			policy: PolicyLFU,
+			max:    2,
+			ops:    []string{"set:a", "set:b", "set:c"},
+			want:   []string{"b", "c"},
+		},
+		{
+			name:   "lfu new key with lowest frequency still fits",
+			policy: PolicyLFU,
+			max:    3,
+			ops:    []string{"set:a", "get:a", "set:b", "get:b", "set:c", "get:c", "set:d", "set:e", "set:f"},
+			want:   []string{"b", "c", "f"},
+		},
+		{
+			name:   "no limit",
+			policy: PolicyLRU,
+			max:    0,
+			ops:    []string{"set:a", "set:b", "set:c"},
+			want:   []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache(t, tt.policy, tt.max, 0)
+			for _, op := range tt.ops {
+				uid := op[4:]
+				switch op[:3] {
+				case "set":
+					c.SetOrder(&model.Order{OrderUUID: uid})
+				case "get":
+					c.GetOrder(uid)
+				}
+				if tt.max > 0 && len(keys(c)) > tt.max {
+					t.Fatalf("after %s: %d entries, limit %d", op, len(keys(c)), tt.max)
+				}
+			}
+
+			if got := keys(c); !slices.Equal(got, tt.want) {
+				t.Errorf("keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	for _, policy := range []string{PolicyLRU, PolicyLFU} {
+		t.Run(policy, func(t *testing.T) {
+			one := orderSize(&model.Order{OrderUUID: "a"})
+			c := newTestCache(t, policy, 0, 2*one)
+
+			for _, uid := range []string{"a", "b", "c", "d"} {
+				c.SetOrder(&model.Order{OrderUUID: uid})
+			}
+
+			n, bytes := c.Len()
+			if n != 2 || bytes > 2*one {
+				t.Errorf("Len() = %d, %d; want 2 entries within %d bytes", n, bytes, 2*one)
+			}
+			if got := c.Stats().Evictions; got != 2 {
+				t.Errorf("Evictions = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestCacheUpdateKeepsSize(t *testing.T) {
+	c := newTestCache(t, PolicyLRU, 10, 0)
+
+	c.SetOrder(&model.Order{OrderUUID: "a", TrackNumber: "long-track-number"})
+	c.SetOrder(&model.Order{OrderUUID: "a"})
+
+	n, bytes := c.Len()
+	if want := orderSize(&model.Order{OrderUUID: "a"}); n != 1 || bytes != want {
+		t.Errorf("Len() = %d, %d; want 1, %d", n, bytes, want)
+	}
+}
+
+func TestSetOrderIfNewer(t *testing.T) {
+	tests := []struct {
+		name    string
+		cached  int64
+		warm    int64
+		want    bool
+		version int64
+	}{
+		{name: "empty cache", cached: 0, warm: 3, want: true, version: 3},
+		{name: "older in cache", cached: 2, warm: 3, want: true, version: 3},
+		{name: "same version", cached: 3, warm: 3, want: false, version: 3},
+		{name: "newer in cache", cached: 5, warm: 3, want: false, version: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache(t, PolicyLRU, 0, 0)
+			if tt.cached > 0 {
+				c.SetOrder(&model.Order{OrderUUID: "a", Version: tt.cached})
+			}
+
+			if got := c.SetOrderIfNewer(&model.Order{OrderUUID: "a", Version: tt.warm}); got != tt.want {
+				t.Errorf("SetOrderIfNewer = %v, want %v", got, tt.want)
+			}
+			order, ok := c.GetOrder("a")
+			if !ok || order.Version != tt.version {
+				t.Errorf("cached version = %v, want %d", order, tt.version)
+			}
+		})
+	}
+}
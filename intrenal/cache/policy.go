@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+	"fmt"
+)
+
+const (
+	PolicyLRU = "lru"
+	PolicyLFU = "lfu"
+)
+
+// Policy решает, какой ключ вытеснить из кеша при переполнении.
+// Методы вызываются под мьютексом Cache, поэтому реализации не обязаны быть потокобезопасными.
+type Policy interface {
+	Add(key string)
+	Touch(key string)
+	Remove(key string)
+	Victim() (string, bool)
+}
+
+func NewPolicy(name string) (Policy, error) {
+	switch name {
+	case PolicyLRU, "":
+		return newLRU(), nil
+	case PolicyLFU:
+		return newLFU(), nil
+	default:
+		return nil, fmt.Errorf("unknown cache eviction policy %q", name)
+	}
+}
+
+// lru вытесняет ключ, к которому дольше всего не обращались
+type lru struct {
+	order *list.List
+	items map[string]*list.Element
+}
+
+func newLRU() *lru {
+	return &lru{
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (p *lru) Add(key string) {
+	if el, ok := p.items[key]; ok {
+		p.order.MoveToFront(el)
+		return
+	}
+	p.items[key] = p.order.PushFront(key)
+}
+
+func (p *lru) Touch(key string) {
+	if el, ok := p.items[key]; ok {
+		p.order.MoveToFront(el)
+	}
+}
+
+func (p *lru) Remove(key string) {
+	if el, ok := p.items[key]; ok {
+		p.order.Remove(el)
+		delete(p.items, key)
+	}
+}
+
+func (p *lru) Victim() (string, bool) {
+	el := p.order.Back()
+	if el == nil {
+		return "", false
+	}
+	return el.Value.(string), true
+}
+
+// lfu вытесняет ключ с наименьшим числом обращений,
+// при равенстве — тот, к которому дольше всего не обращались
+type lfu struct {
+	heap  lfuHeap
+	items map[string]*lfuItem
+	tick  uint64
+}
+
+type lfuItem struct {
+	key   string
+	freq  uint64
+	tick  uint64
+	index int
+}
+
+type lfuHeap []*lfuItem
+
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].tick < h[j].tick
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x any) {
+	item := x.(*lfuItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *lfuHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+func newLFU() *lfu {
+	return &lfu{
+		items: make(map[string]*lfuItem),
+	}
+}
+
+func (p *lfu) Add(key string) {
+	if _, ok := p.items[key]; ok {
+		p.Touch(key)
+		return
+	}
+	p.tick++
+	item := &lfuItem{key: key, freq: 1, tick: p.tick}
+	heap.Push(&p.heap, item)
+	p.items[key] = item
+}
+
+func (p *lfu) Touch(key string) {
+	item, ok := p.items[key]
+	if !ok {
+		return
+	}
+	p.tick++
+	item.freq++
+	item.tick = p.tick
+	heap.Fix(&p.heap, item.index)
+}
+
+func (p *lfu) Remove(key string) {
+	item, ok := p.items[key]
+	if !ok {
+		return
+	}
+	heap.Remove(&p.heap, item.index)
+	delete(p.items, key)
+}
+
+func (p *lfu) Victim() (string, bool) {
+	if len(p.heap) == 0 {
+		return "", false
+	}
+	return p.heap[0].key, true
+}
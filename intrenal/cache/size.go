@@ -0,0 +1,28 @@
+package cache
+
+import (
+	model "WB_Service/intrenal/models"
+	"unsafe"
+)
+
+// orderSize приблизительно оценивает объём памяти, занимаемый заказом:
+// размер структур плюс длины всех строк
+func orderSize(order *model.Order) int64 {
+	size := int64(unsafe.Sizeof(*order))
+	size += int64(len(order.OrderUUID) + len(order.TrackNumber) + len(order.Entry) + len(order.Locale) +
+		len(order.InternalSignature) + len(order.CustomerID) + len(order.DeliveryService) +
+		len(order.Shardkey) + len(order.OOFShard))
+
+	d := order.Delivery
+	size += int64(len(d.Name) + len(d.Phone) + len(d.Zip) + len(d.City) + len(d.Address) + len(d.Region) + len(d.Email))
+
+	p := order.Payment
+	size += int64(len(p.Transaction) + len(p.RequestId) + len(p.Currency) + len(p.Provider) + len(p.Bank))
+
+	size += int64(cap(order.Items)) * int64(unsafe.Sizeof(model.Item{}))
+	for _, item := range order.Items {
+		size += int64(len(item.TrackNumber) + len(item.Rid) + len(item.Name) + len(item.Size) + len(item.Brand))
+	}
+
+	return size
+}
@@ -1,7 +1,14 @@
 package config
 
 import (
+	"WB_Service/intrenal/cache"
 	"WB_Service/intrenal/db"
+	"WB_Service/intrenal/kafka/outbox"
+	"WB_Service/intrenal/kafka/producer"
+	"WB_Service/intrenal/lib/backoff"
+	model "WB_Service/intrenal/models"
+	"WB_Service/intrenal/service"
+	"WB_Service/intrenal/validation"
 	"flag"
 	"github.com/ilyakaznacheev/cleanenv"
 	"os"
@@ -9,23 +16,48 @@ import (
 )
 
 type Config struct {
-	Env        string            `yaml:"env" env-required:"true"`
-	TTl        time.Duration     `yaml:"ttl" env-default:"10s"`
-	HTTPConfig HTTP              `yaml:"http" env-required:"true"`
-	Postgres   db.PostgresConfig `yaml:"postgres" env-required:"true"`
-	Kafka      Kafka             `yaml:"kafka" env-default:"kafka"`
+	Env             string                    `yaml:"env" env-required:"true"`
+	ShutdownTimeout time.Duration             `yaml:"shutdown_timeout" env-default:"30s"`
+	HTTPConfig      HTTP                      `yaml:"http" env-required:"true"`
+	Postgres        db.PostgresConfig         `yaml:"postgres" env-required:"true"`
+	Kafka           Kafka                     `yaml:"kafka" env-default:"kafka"`
+	Producer        producer.Config           `yaml:"producer"`
+	Cache           cache.Config              `yaml:"cache"`
+	Validation      validation.Config         `yaml:"validation"`
+	Idempotency     service.IdempotencyConfig `yaml:"idempotency"`
+	Outbox          outbox.Config             `yaml:"outbox"`
+	Stats           service.StatsConfig       `yaml:"stats"`
 }
 
 type Kafka struct {
 	Brokers []string `yaml:"brokers" env-required:"true"`
 	Topic   string   `yaml:"topic" env-required:"true"`
 	GroupID string   `yaml:"group_id" env-required:"true"`
+
+	// StatusTopic — события смены статуса заказа, читаются той же группой; пусто — не читать
+	StatusTopic string `yaml:"status_topic" env-default:"order-status"`
+	// StatusRetryTopic — отложенные события статуса для ещё не пришедших заказов; пусто — сразу в DLQ
+	StatusRetryTopic string `yaml:"status_retry_topic" env-default:"order-status-retry"`
+
+	DLQTopic    string         `yaml:"dlq_topic" env-default:"orders-dlq"`
+	MaxAttempts int            `yaml:"max_attempts" env-default:"10"`
+	Retry       backoff.Config `yaml:"retry"`
+	Workers     int            `yaml:"workers" env-default:"4"`
+
+	BatchSize    int           `yaml:"batch_size" env-default:"100"`
+	BatchTimeout time.Duration `yaml:"batch_timeout" env-default:"200ms"`
+
+	// OutOfOrder — политика для событий заказа без версии, пришедших не по порядку: skip | last_write_wins.
+	// skip пропускает события с date_created не новее сохранённого, last_write_wins — только более старые.
+	OutOfOrder model.OutOfOrderPolicy `yaml:"out_of_order" env-default:"skip"`
 }
 
 type HTTP struct {
 	Address     string        `yaml:"address"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"120s"`
+
+	HealthTimeout time.Duration `yaml:"health_timeout" env-default:"2s"`
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,148 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+// размер страницы order_uid при полной выгрузке заказов
+const ordersPageSize = 500
+
+const orderHeaderQuery = `SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+       o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.status, o.version,
+       d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+       p.transaction, p.request_id, p.currency, p.provider, p.amount, p.payment_dt, p.bank,
+       p.delivery_cost, p.goods_total, p.custom_fee
+FROM orders o
+JOIN delivery d ON d.order_uid = o.order_uid
+JOIN payment p ON p.order_uid = o.order_uid
+WHERE o.order_uid = ANY($1)`
+
+const orderItemsQuery = `SELECT order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+FROM items
+WHERE order_uid = ANY($1)
+ORDER BY order_uid, id`
+
+// GetOrdersByUIDs загружает заказы вместе с delivery, payment и items за один round-trip
+// независимо от количества uid. Результат идёт в порядке uids, отсутствующие заказы пропускаются.
+func (p *Postgres) GetOrdersByUIDs(ctx context.Context, uids []string) ([]*model.Order, error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+
+	return getOrdersByUIDs(ctx, p.pool, uids)
+}
+
+// batchSender — пул или транзакция, в которой нужно прочитать заказы
+type batchSender interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+func getOrdersByUIDs(ctx context.Context, conn batchSender, uids []string) ([]*model.Order, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(orderHeaderQuery, uids)
+	batch.Queue(orderItemsQuery, uids)
+
+	br := conn.SendBatch(ctx, batch)
+	defer br.Close()
+
+	byUID := make(map[string]*model.Order, len(uids))
+
+	// заказы + delivery + payment
+	rows, err := br.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	for rows.Next() {
+		var order model.Order
+		if err := rows.Scan(&order.OrderUUID,
+			&order.TrackNumber,
+			&order.Entry,
+			&order.Locale,
+			&order.InternalSignature,
+			&order.CustomerID,
+			&order.DeliveryService,
+			&order.Shardkey,
+			&order.SmID,
+			&order.DateCreated,
+			&order.OOFShard,
+			&order.Status,
+			&order.Version,
+			&order.Delivery.Name,
+			&order.Delivery.Phone,
+			&order.Delivery.Zip,
+			&order.Delivery.City,
+			&order.Delivery.Address,
+			&order.Delivery.Region,
+			&order.Delivery.Email,
+			&order.Payment.Transaction,
+			&order.Payment.RequestId,
+			&order.Payment.Currency,
+			&order.Payment.Provider,
+			&order.Payment.Amount,
+			&order.Payment.Payment,
+			&order.Payment.Bank,
+			&order.Payment.DeliveryCost,
+			&order.Payment.GoodsTotal,
+			&order.Payment.CustomFee,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		byUID[order.OrderUUID] = &order
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	// items
+	rows, err = br.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+	for rows.Next() {
+		var (
+			orderUID string
+			item     model.Item
+		)
+		if err := rows.Scan(&orderUID,
+			&item.ChrtID,
+			&item.TrackNumber,
+			&item.Price,
+			&item.Rid,
+			&item.Name,
+			&item.Sale,
+			&item.Size,
+			&item.TotalPrice,
+			&item.NmID,
+			&item.Brand,
+			&item.Status,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		if order, ok := byUID[orderUID]; ok {
+			order.Items = append(order.Items, item)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	orders := make([]*model.Order, 0, len(byUID))
+	for _, uid := range uids {
+		if order, ok := byUID[uid]; ok {
+			orders = append(orders, order)
+		}
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// exportFetchSize — сколько заказов читается из курсора за раз
+const exportFetchSize = 500
+
+// ExportOrders передаёт в fn все заказы, подходящие под фильтр, в порядке (date_created, order_uid).
+// Заказы читаются серверным курсором порциями в одной read-only транзакции,
+// поэтому выгрузка видит согласованный снимок и не держит всё в памяти.
+// Limit и Cursor фильтра не учитываются. Отмена ctx прерывает выгрузку.
+func (p *Postgres) ExportOrders(ctx context.Context, f model.OrderFilter, fn func(*model.Order) error) error {
+	if p.pool == nil {
+		return fmt.Errorf("pool is nil")
+	}
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer p.rollback(ctx, tx)
+
+	w := orderFilterWhere(f)
+	_, err = tx.Exec(ctx, `DECLARE export_orders NO SCROLL CURSOR FOR
+SELECT o.order_uid FROM orders o JOIN payment p ON p.order_uid = o.order_uid`+w.String()+`
+ORDER BY o.date_created, o.order_uid`, w.args...)
+	if err != nil {
+		return fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf(`FETCH %d FROM export_orders`, exportFetchSize))
+		if err != nil {
+			return fmt.Errorf("failed to fetch export cursor: %w", err)
+		}
+		uids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return fmt.Errorf("failed to scan order_uid: %w", err)
+		}
+		if len(uids) == 0 {
+			return nil
+		}
+
+		orders, err := getOrdersByUIDs(ctx, tx, uids)
+		if err != nil {
+			return err
+		}
+		for _, order := range orders {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+	}
+}
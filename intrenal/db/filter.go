@@ -0,0 +1,157 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// whereBuilder собирает условия WHERE с позиционными параметрами
+type whereBuilder struct {
+	conds []string
+	args  []any
+}
+
+// arg добавляет параметр и возвращает его плейсхолдер, когда значение нужно в нескольких местах запроса
+func (w *whereBuilder) arg(v any) string {
+	w.args = append(w.args, v)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+func (w *whereBuilder) add(cond string, args ...any) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		cond = strings.Replace(cond, "?", fmt.Sprintf("$%d", len(w.args)), 1)
+	}
+	w.conds = append(w.conds, cond)
+}
+
+func (w *whereBuilder) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+// orderFilterWhere переводит фильтр в условия над orders o и payment p
+func orderFilterWhere(f model.OrderFilter) *whereBuilder {
+	w := &whereBuilder{}
+
+	if f.CustomerID != "" {
+		w.add("o.customer_id = ?", f.CustomerID)
+	}
+	if f.DeliveryService != "" {
+		w.add("o.delivery_service = ?", f.DeliveryService)
+	}
+	if f.Currency != "" {
+		w.add("p.currency = ?", f.Currency)
+	}
+	if f.Provider != "" {
+		w.add("p.provider = ?", f.Provider)
+	}
+	if f.Bank != "" {
+		w.add("p.bank = ?", f.Bank)
+	}
+	if f.DateFrom != nil {
+		w.add("o.date_created >= ?", f.DateFrom.UTC())
+	}
+	if f.DateTo != nil {
+		w.add("o.date_created < ?", f.DateTo.UTC())
+	}
+	if f.AmountMin != nil {
+		w.add("p.amount >= ?", *f.AmountMin)
+	}
+	if f.AmountMax != nil {
+		w.add("p.amount <= ?", *f.AmountMax)
+	}
+	if f.Query != "" {
+		w.addTextMatch(f.Query)
+	}
+
+	return w
+}
+
+// ListOrders возвращает страницу заказов, упорядоченных по (date_created, order_uid),
+// а с полнотекстовым запросом — по (релевантность DESC, date_created, order_uid)
+func (p *Postgres) ListOrders(ctx context.Context, f model.OrderFilter) (*model.OrderPage, error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+
+	w := orderFilterWhere(f)
+
+	// берём на одну запись больше, чтобы понять, есть ли следующая страница
+	var query string
+	if f.Query == "" {
+		if f.Cursor != nil {
+			w.add("(o.date_created, o.order_uid) > (?, ?)", f.Cursor.DateCreated.UTC(), f.Cursor.OrderUID)
+		}
+		query = `SELECT o.order_uid, o.date_created, 0::real FROM orders o JOIN payment p ON p.order_uid = o.order_uid` + w.String() +
+			fmt.Sprintf(" ORDER BY o.date_created, o.order_uid LIMIT %d", f.Limit+1)
+	} else {
+		rank := w.textRank(f.Query)
+		query = `SELECT r.order_uid, r.date_created, r.rank FROM (SELECT o.order_uid, o.date_created, ` + rank + ` AS rank
+FROM orders o JOIN payment p ON p.order_uid = o.order_uid` + w.String() + `) r`
+		if f.Cursor != nil {
+			c := &whereBuilder{args: w.args}
+			c.add("(r.rank < ? OR (r.rank = ? AND (r.date_created, r.order_uid) > (?, ?)))",
+				*f.Cursor.Rank, *f.Cursor.Rank, f.Cursor.DateCreated.UTC(), f.Cursor.OrderUID)
+			query += c.String()
+			w.args = c.args
+		}
+		query += fmt.Sprintf(" ORDER BY r.rank DESC, r.date_created, r.order_uid LIMIT %d", f.Limit+1)
+	}
+
+	rows, err := p.pool.Query(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	defer rows.Close()
+
+	// курсор строится по выбранным строкам: заказ может пропасть до GetOrdersByUIDs
+	type position struct {
+		uid         string
+		dateCreated time.Time
+		rank        float32
+	}
+	positions := make([]position, 0, f.Limit+1)
+	for rows.Next() {
+		var pos position
+		if err := rows.Scan(&pos.uid, &pos.dateCreated, &pos.rank); err != nil {
+			return nil, fmt.Errorf("failed to scan order_uid: %w", err)
+		}
+		positions = append(positions, pos)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	hasMore := len(positions) > f.Limit
+	if hasMore {
+		positions = positions[:f.Limit]
+	}
+
+	uids := make([]string, len(positions))
+	for i, pos := range positions {
+		uids[i] = pos.uid
+	}
+
+	orders, err := p.GetOrdersByUIDs(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.OrderPage{Orders: orders}
+	if hasMore && len(positions) > 0 {
+		last := positions[len(positions)-1]
+		cursor := model.OrderCursor{DateCreated: last.dateCreated, OrderUID: last.uid}
+		if f.Query != "" {
+			cursor.Rank = &last.rank
+		}
+		page.NextCursor = cursor.Encode()
+	}
+
+	return page, nil
+}
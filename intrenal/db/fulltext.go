@@ -0,0 +1,63 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// searchConfigs — конфигурации словарей, с которыми строятся векторы заказов.
+// Запрос сравнивается со всеми, потому что язык запроса неизвестен.
+var searchConfigs = []string{"russian", "english", "simple"}
+
+// searchConfig выбирает конфигурацию словаря по locale заказа
+func searchConfig(locale string) string {
+	switch strings.ToLower(locale) {
+	case "ru":
+		return "russian"
+	case "en":
+		return "english"
+	default:
+		return "simple"
+	}
+}
+
+// searchVectorSQL — выражение search_vector заказа o по его items и delivery d
+// с конфигурацией словаря cfg (SQL-выражение типа regconfig).
+// Товары весят больше адреса, чтобы совпадение по бренду поднималось выше.
+func searchVectorSQL(cfg string) string {
+	return `setweight(to_tsvector(` + cfg + `, coalesce((SELECT string_agg(concat_ws(' ', i.name, i.brand), ' ')
+                                   FROM items i WHERE i.order_uid = o.order_uid), '')), 'A') ||
+    setweight(to_tsvector(` + cfg + `, concat_ws(' ', d.city, d.address, d.region)), 'B')`
+}
+
+// queueSearchVector пересчитывает search_vector заказа по уже записанным items и delivery
+func queueSearchVector(b *pgx.Batch, order *model.Order) {
+	b.Queue(`UPDATE orders o SET search_vector = `+searchVectorSQL("$2::regconfig")+`
+FROM delivery d
+WHERE d.order_uid = o.order_uid AND o.order_uid = $1`,
+		order.OrderUUID, searchConfig(order.Locale),
+	)
+}
+
+// addTextMatch добавляет условие совпадения search_vector с запросом q
+func (w *whereBuilder) addTextMatch(q string) {
+	param := w.arg(q)
+	matches := make([]string, 0, len(searchConfigs))
+	for _, cfg := range searchConfigs {
+		matches = append(matches, fmt.Sprintf("o.search_vector @@ websearch_to_tsquery('%s', %s)", cfg, param))
+	}
+	w.conds = append(w.conds, "("+strings.Join(matches, " OR ")+")")
+}
+
+// textRank возвращает выражение релевантности заказа запросу q
+func (w *whereBuilder) textRank(q string) string {
+	param := w.arg(q)
+	ranks := make([]string, 0, len(searchConfigs))
+	for _, cfg := range searchConfigs {
+		ranks = append(ranks, fmt.Sprintf("ts_rank(o.search_vector, websearch_to_tsquery('%s', %s))", cfg, param))
+	}
+	return "GREATEST(" + strings.Join(ranks, ", ") + ")"
+}
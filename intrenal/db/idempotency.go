@@ -0,0 +1,91 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ReserveIdempotencyKey занимает ключ за запросом с хешем requestHash на время lease.
+// Возвращает nil, если ключ свободен (истёк или брошен без ответа после lease) и теперь занят нами,
+// иначе — уже существующую запись.
+func (p *Postgres) ReserveIdempotencyKey(ctx context.Context, key, requestHash string, ttl, lease time.Duration) (*model.IdempotencyRecord, error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+
+	// истёкший ключ перезаписываем, как будто его не было; так же забираем
+	// ключ, чей запрос не записал ответ до locked_until (упал процесс или оборвался запрос)
+	now := time.Now()
+	tag, err := p.pool.Exec(ctx,
+		`INSERT INTO idempotency_keys (key, request_hash, expires_at, locked_until) VALUES ($1, $2, $3, $4)
+        ON CONFLICT (key) DO UPDATE SET request_hash=EXCLUDED.request_hash,
+                                        response=NULL,
+                                        created_at=now(),
+                                        expires_at=EXCLUDED.expires_at,
+                                        locked_until=EXCLUDED.locked_until
+        WHERE idempotency_keys.expires_at < now()
+           OR (idempotency_keys.response IS NULL AND idempotency_keys.locked_until < now())`,
+		key, requestHash, now.Add(ttl), now.Add(lease),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	record := model.IdempotencyRecord{Key: key}
+	err = p.pool.QueryRow(ctx,
+		`SELECT request_hash, response, expires_at FROM idempotency_keys WHERE key = $1`, key,
+	).Scan(&record.RequestHash, &record.Response, &record.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// ключ успели удалить между запросами — пробуем ещё раз
+		return p.ReserveIdempotencyKey(ctx, key, requestHash, ttl, lease)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &record, nil
+}
+
+func (p *Postgres) SaveIdempotencyResponse(ctx context.Context, key string, response []byte) error {
+	if p.pool == nil {
+		return fmt.Errorf("pool is nil")
+	}
+
+	_, err := p.pool.Exec(ctx, `UPDATE idempotency_keys SET response = $2, locked_until = NULL WHERE key = $1`, key, response)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency response: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	if p.pool == nil {
+		return fmt.Errorf("pool is nil")
+	}
+
+	_, err := p.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND response IS NULL`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	if p.pool == nil {
+		return 0, fmt.Errorf("pool is nil")
+	}
+
+	tag, err := p.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at < now()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,204 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Временные таблицы для COPY: те же типы колонок, что и у основных таблиц, без ограничений
+var importStaging = []string{
+	`CREATE TEMP TABLE import_orders ON COMMIT DROP AS
+SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+FROM orders WITH NO DATA`,
+	// expected_version — версия из файла (0 — без версии), search_config — словарь по locale, см. searchConfig
+	`ALTER TABLE import_orders ADD COLUMN snapshot JSONB, ADD COLUMN expected_version BIGINT, ADD COLUMN search_config TEXT`,
+	`CREATE TEMP TABLE import_delivery ON COMMIT DROP AS
+SELECT order_uid, name, phone, zip, city, address, region, email FROM delivery WITH NO DATA`,
+	`CREATE TEMP TABLE import_payment ON COMMIT DROP AS
+SELECT order_uid, transaction, request_id, currency, provider, amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
+FROM payment WITH NO DATA`,
+	`CREATE TEMP TABLE import_items ON COMMIT DROP AS
+SELECT order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status FROM items WITH NO DATA`,
+	`ALTER TABLE import_items ADD COLUMN pos INT`,
+}
+
+// ImportOrders сохраняет пачку заказов одной транзакцией: данные загружаются COPY во временные таблицы,
+// затем переносятся upsert-ами. Как и при обычном сохранении, пишутся события outbox, ревизии
+// и полнотекстовый вектор. Устаревшие заказы отбираются тем же условием, что и при обычном сохранении
+// (ожидаемая версия и policy, см. orderWriteAllowed), не перезаписываются и возвращаются в stale.
+// order_uid в пачке должны быть уникальны.
+func (p *Postgres) ImportOrders(ctx context.Context, orders []*model.Order, policy model.OutOfOrderPolicy) (stale []string, err error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer p.rollback(ctx, tx)
+
+	for _, stmt := range importStaging {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("failed to create import staging tables: %w", err)
+		}
+	}
+
+	orderRows := make([][]any, 0, len(orders))
+	deliveryRows := make([][]any, 0, len(orders))
+	paymentRows := make([][]any, 0, len(orders))
+	var itemRows [][]any
+
+	for _, o := range orders {
+		snapshot, err := orderSnapshot(o)
+		if err != nil {
+			return nil, err
+		}
+		orderRows = append(orderRows, []any{o.OrderUUID, o.TrackNumber, o.Entry, o.Locale, o.InternalSignature,
+			o.CustomerID, o.DeliveryService, o.Shardkey, o.SmID, o.DateCreated, o.OOFShard, snapshot, o.Version,
+			searchConfig(o.Locale)})
+		deliveryRows = append(deliveryRows, []any{o.OrderUUID, o.Delivery.Name, o.Delivery.Phone, o.Delivery.Zip,
+			o.Delivery.City, o.Delivery.Address, o.Delivery.Region, o.Delivery.Email})
+		paymentRows = append(paymentRows, []any{o.OrderUUID, o.Payment.Transaction, o.Payment.RequestId, o.Payment.Currency,
+			o.Payment.Provider, o.Payment.Amount, o.Payment.Payment, o.Payment.Bank, o.Payment.DeliveryCost,
+			o.Payment.GoodsTotal, o.Payment.CustomFee})
+		for i, it := range o.Items {
+			itemRows = append(itemRows, []any{o.OrderUUID, it.ChrtID, it.TrackNumber, it.Price, it.Rid, it.Name,
+				it.Sale, it.Size, it.TotalPrice, it.NmID, it.Brand, it.Status, i})
+		}
+	}
+
+	copies := []struct {
+		table   string
+		columns []string
+		rows    [][]any
+	}{
+		{"import_orders", []string{"order_uid", "track_number", "entry", "locale", "internal_signature", "customer_id",
+			"delivery_service", "shardkey", "sm_id", "date_created", "oof_shard", "snapshot", "expected_version",
+			"search_config"}, orderRows},
+		{"import_delivery", []string{"order_uid", "name", "phone", "zip", "city", "address", "region", "email"}, deliveryRows},
+		{"import_payment", []string{"order_uid", "transaction", "request_id", "currency", "provider", "amount",
+			"payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee"}, paymentRows},
+		{"import_items", []string{"order_uid", "chrt_id", "track_number", "price", "rid", "name", "sale", "size",
+			"total_price", "nm_id", "brand", "status", "pos"}, itemRows},
+	}
+	for _, c := range copies {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{c.table}, c.columns, pgx.CopyFromRows(c.rows)); err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %w", c.table, err)
+		}
+	}
+
+	// существующие заказы блокируем до конца транзакции и убираем из пачки те, что не прошли бы
+	// обычное сохранение: исторический дамп не должен откатывать живые данные
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM orders o JOIN import_orders s ON s.order_uid = o.order_uid
+ORDER BY o.order_uid FOR UPDATE OF o`); err != nil {
+		return nil, fmt.Errorf("failed to lock imported orders: %w", err)
+	}
+	rows, err := tx.Query(ctx, `DELETE FROM import_orders s USING orders o
+WHERE o.order_uid = s.order_uid AND NOT (`+orderWriteAllowed("s", "o", "s.expected_version", "$1::text")+`)
+RETURNING s.order_uid`, string(policy))
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter stale imported orders: %w", err)
+	}
+	stale, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter stale imported orders: %w", err)
+	}
+	if len(stale) == len(orders) {
+		return stale, nil
+	}
+
+	b := &pgx.Batch{}
+	if len(stale) > 0 {
+		for _, table := range []string{"import_delivery", "import_payment", "import_items"} {
+			b.Queue(`DELETE FROM `+table+` WHERE order_uid = ANY($1)`, stale)
+		}
+	}
+
+	b.Queue(`INSERT INTO orders (order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard)
+SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+FROM import_orders
+ON CONFLICT (order_uid) DO UPDATE SET ` + orderUpsertSet)
+
+	// событие outbox — после upsert: статус и версия из сохранённой строки, версия 1 — новый заказ
+	b.Queue(`INSERT INTO order_events (order_uid, event_type, payload)
+SELECT s.order_uid, CASE WHEN o.version = 1 THEN $1 ELSE $2 END,
+       s.snapshot || jsonb_build_object('status', o.status, 'version', o.version)
+FROM import_orders s JOIN orders o ON o.order_uid = s.order_uid`,
+		model.EventOrderCreated, model.EventOrderUpdated)
+
+	b.Queue(`INSERT INTO delivery (order_uid, name, phone, zip, city, address, region, email)
+SELECT order_uid, name, phone, zip, city, address, region, email FROM import_delivery
+ON CONFLICT (order_uid) DO UPDATE SET name=EXCLUDED.name,
+                                      phone=EXCLUDED.phone,
+                                      zip=EXCLUDED.zip,
+                                      city=EXCLUDED.city,
+                                      address=EXCLUDED.address,
+                                      region=EXCLUDED.region,
+                                      email=EXCLUDED.email`)
+
+	b.Queue(`INSERT INTO payment (order_uid, transaction, request_id, currency, provider, amount, payment_dt, bank, delivery_cost, goods_total, custom_fee)
+SELECT order_uid, transaction, request_id, currency, provider, amount, payment_dt, bank, delivery_cost, goods_total, custom_fee FROM import_payment
+ON CONFLICT (order_uid) DO UPDATE SET transaction=EXCLUDED.transaction,
+                                      request_id=EXCLUDED.request_id,
+                                      currency=EXCLUDED.currency,
+                                      provider=EXCLUDED.provider,
+                                      amount=EXCLUDED.amount,
+                                      payment_dt=EXCLUDED.payment_dt,
+                                      bank=EXCLUDED.bank,
+                                      delivery_cost=EXCLUDED.delivery_cost,
+                                      goods_total=EXCLUDED.goods_total,
+                                      custom_fee=EXCLUDED.custom_fee`)
+
+	// набор товаров заменяем целиком, сохраняя порядок из файла
+	b.Queue(`DELETE FROM items WHERE order_uid IN (SELECT order_uid FROM import_orders)`)
+	b.Queue(`INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status)
+SELECT order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+FROM import_items ORDER BY order_uid, pos`)
+
+	b.Queue(`INSERT INTO order_revisions (order_uid, revision, snapshot)
+SELECT s.order_uid, COALESCE(l.revision, 0) + 1, s.snapshot
+FROM import_orders s
+LEFT JOIN LATERAL (SELECT revision, snapshot FROM order_revisions r
+                   WHERE r.order_uid = s.order_uid ORDER BY revision DESC LIMIT 1) l ON true
+WHERE l.snapshot IS DISTINCT FROM s.snapshot`)
+
+	b.Queue(`UPDATE orders o SET search_vector = ` + searchVectorSQL("s.search_config::regconfig") + `
+FROM import_orders s
+JOIN delivery d ON d.order_uid = s.order_uid
+WHERE o.order_uid = s.order_uid`)
+
+	var steps []batchStep
+	if len(stale) > 0 {
+		steps = append(steps,
+			batchStep{name: "drop stale delivery"},
+			batchStep{name: "drop stale payment"},
+			batchStep{name: "drop stale items"},
+		)
+	}
+	steps = append(steps,
+		batchStep{name: "import orders"},
+		batchStep{name: "import events"},
+		batchStep{name: "import delivery"},
+		batchStep{name: "import payment"},
+		batchStep{name: "import old items"},
+		batchStep{name: "import items"},
+		batchStep{name: "import revisions"},
+		batchStep{name: "import search vectors"},
+	)
+	if err := execBatch(ctx, tx, b, steps); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return stale, nil
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// queueOrderEvent ставит в пачку запись outbox. Должна идти после upsert заказа: статус и версия
+// в событии берутся из сохранённой строки, а не из входящего заказа. Только что вставленный заказ
+// имеет версию 1 (любое изменение её увеличивает) — так создание отличается от изменения.
+func queueOrderEvent(b *pgx.Batch, order *model.Order) error {
+	payload, err := orderSnapshot(order)
+	if err != nil {
+		return err
+	}
+
+	b.Queue(`INSERT INTO order_events (order_uid, event_type, payload)
+SELECT order_uid, CASE WHEN version = 1 THEN $2 ELSE $3 END,
+       $4::jsonb || jsonb_build_object('status', status, 'version', version)
+FROM orders WHERE order_uid = $1`,
+		order.OrderUUID, model.EventOrderCreated, model.EventOrderUpdated, payload,
+	)
+	return nil
+}
+
+// время на отметку результатов отправки: события уже в Kafka, поэтому отметка не прерывается вместе с ctx
+const outboxMarkTimeout = 5 * time.Second
+
+// ProcessOutbox захватывает до limit неотправленных событий на время lease и передаёт их в publish.
+// Захват — отдельный короткий запрос (FOR UPDATE SKIP LOCKED + locked_until), отправка идёт вне
+// транзакции, результаты записываются следующей короткой транзакцией. Поэтому несколько реплик
+// не отправят одно событие одновременно, а медленный брокер не держит блокировки строк.
+// Если relay не отметил событие до конца lease, его захватит повторно любая реплика (at-least-once).
+// Событие заказа не выбирается, пока не отправлены более ранние события того же заказа.
+// После неудачи событие откладывается на retryDelay(attempts).
+func (p *Postgres) ProcessOutbox(ctx context.Context, limit int, lease time.Duration, publish func(model.OrderEvent) error, retryDelay func(attempts int) time.Duration) (sent, failed int, err error) {
+	if p.pool == nil {
+		return 0, 0, fmt.Errorf("pool is nil")
+	}
+
+	events, err := p.claimOutbox(ctx, limit, lease)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(events) == 0 {
+		return 0, 0, nil
+	}
+
+	b := &pgx.Batch{}
+	steps := make([]batchStep, 0, len(events))
+	for _, e := range events {
+		if pubErr := publish(e); pubErr != nil {
+			b.Queue(`UPDATE order_events SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3, locked_until = NULL WHERE id = $1`,
+				e.ID, pubErr.Error(), time.Now().Add(retryDelay(e.Attempts+1)),
+			)
+			failed++
+		} else {
+			b.Queue(`UPDATE order_events SET sent_at = now(), attempts = attempts + 1, last_error = NULL, locked_until = NULL WHERE id = $1`, e.ID)
+			sent++
+		}
+		steps = append(steps, batchStep{name: fmt.Sprintf("outbox event %d", e.ID)})
+	}
+
+	markCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), outboxMarkTimeout)
+	defer cancel()
+
+	tx, err := p.pool.Begin(markCtx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer p.rollback(markCtx, tx)
+
+	if err := execBatch(markCtx, tx, b, steps); err != nil {
+		return 0, 0, err
+	}
+	if err := tx.Commit(markCtx); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit outbox: %w", err)
+	}
+
+	return sent, failed, nil
+}
+
+// claimOutbox одним запросом (и значит одной короткой транзакцией) ставит аренду на готовые к отправке
+// события и возвращает их по возрастанию id
+func (p *Postgres) claimOutbox(ctx context.Context, limit int, lease time.Duration) ([]model.OrderEvent, error) {
+	rows, err := p.pool.Query(ctx,
+		`WITH claimed AS (
+    SELECT e.id
+    FROM order_events e
+    WHERE e.sent_at IS NULL
+      AND e.next_attempt_at <= now()
+      AND (e.locked_until IS NULL OR e.locked_until < now())
+      AND NOT EXISTS (SELECT 1 FROM order_events prev
+                      WHERE prev.order_uid = e.order_uid AND prev.sent_at IS NULL AND prev.id < e.id)
+    ORDER BY e.id
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE order_events e SET locked_until = $2
+FROM claimed
+WHERE e.id = claimed.id
+RETURNING e.id, e.order_uid, e.event_type, e.payload, e.attempts, e.created_at`, limit, time.Now().Add(lease))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.OrderEvent, error) {
+		var e model.OrderEvent
+		err := row.Scan(&e.ID, &e.OrderUID, &e.Type, &e.Payload, &e.Attempts, &e.CreatedAt)
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan outbox events: %w", err)
+	}
+
+	// RETURNING не гарантирует порядок
+	slices.SortFunc(events, func(a, b model.OrderEvent) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
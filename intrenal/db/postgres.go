@@ -12,11 +12,15 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log/slog"
+	"time"
 )
 
 type Postgres struct {
 	pool *pgxpool.Pool
 	log  *slog.Logger
+
+	// версия схемы после применения миграций при старте
+	migrationVersion uint
 }
 
 type PostgresConfig struct {
@@ -63,17 +67,62 @@ func NewPostgres(ctx context.Context, config PostgresConfig, log *slog.Logger) (
 		return nil, err
 	}
 
+	defer m.Close()
+
 	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return nil, fmt.Errorf("failed to migrate to database: %w", err)
 	}
 
+	version, _, err := m.Version()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration version: %w", err)
+	}
+
 	return &Postgres{
-		pool: conn,
-		log:  log,
+		pool:             conn,
+		log:              log,
+		migrationVersion: version,
 	}, nil
 }
 
-func (p *Postgres) SaveUserData(ctx context.Context, order *model.Order) error {
+func (p *Postgres) Close() {
+	p.pool.Close()
+}
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
+// CheckMigrations проверяет, что схема не грязная и не откатилась ниже версии, с которой стартовал сервис
+func (p *Postgres) CheckMigrations(ctx context.Context) error {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := p.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		return fmt.Errorf("failed to read migration state: %w", err)
+	}
+
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	if uint(version) < p.migrationVersion {
+		return fmt.Errorf("schema version %d is behind expected %d", version, p.migrationVersion)
+	}
+	return nil
+}
+
+// Stat возвращает статистику пула соединений
+func (p *Postgres) Stat() *pgxpool.Stat {
+	return p.pool.Stat()
+}
+
+// SaveUserData сохраняет заказ. Устаревшая запись отклоняется:
+// ErrVersionConflict — ожидаемая order.Version не совпала с текущей (при любой policy),
+// ErrStaleOrder — заказ без версии, а date_created старше сохранённого
+// (при policy skip — не новее сохранённого).
+func (p *Postgres) SaveUserData(ctx context.Context, order *model.Order, policy model.OutOfOrderPolicy) error {
 	if p.pool == nil {
 		return fmt.Errorf("pool is nil")
 	}
@@ -82,16 +131,119 @@ func (p *Postgres) SaveUserData(ctx context.Context, order *model.Order) error {
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
-	defer func(tx pgx.Tx, ctx context.Context) {
-		err := tx.Rollback(ctx)
+	defer p.rollback(ctx, tx)
+
+	b := &pgx.Batch{}
+	steps, err := queueOrder(b, order, policy)
+	if err != nil {
+		return err
+	}
+	if err := execBatch(ctx, tx, b, steps); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ErrSkippedInBatch — заказ в пачке не записывался, потому что раньше в ней не сохранилась
+// другая версия того же order_uid; своей попытки у него ещё не было
+var ErrSkippedInBatch = errors.New("skipped after earlier failure of the same order")
+
+// SaveUserDataBatch сохраняет несколько заказов в одной транзакции.
+// Каждый заказ пишется под своим savepoint, поэтому ошибка в одном заказе не откатывает остальные:
+// она возвращается в errs по индексу заказа. Если не удалось записать заказ, следующие версии
+// того же order_uid в пачке пропускаются, чтобы не нарушить порядок при повторной обработке.
+// Отклонённая как устаревшая запись порядок не нарушает и следующие версии не блокирует.
+// Ошибка err означает, что не сохранился ни один заказ.
+func (p *Postgres) SaveUserDataBatch(ctx context.Context, orders []*model.Order, policy model.OutOfOrderPolicy) (errs []error, err error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer p.rollback(ctx, tx)
+
+	errs = make([]error, len(orders))
+	failed := make(map[string]error)
+
+	for i, order := range orders {
+		if prev, ok := failed[order.OrderUUID]; ok {
+			errs[i] = fmt.Errorf("%w: %w", ErrSkippedInBatch, prev)
+			continue
+		}
+
+		b := &pgx.Batch{}
+		b.Queue(`SAVEPOINT batch_order`)
+		steps, err := queueOrder(b, order, policy)
 		if err != nil {
-			p.log.Error("Rollback failed", sl.Err(err))
+			errs[i] = err
+			failed[order.OrderUUID] = err
+			continue
+		}
+		steps = append([]batchStep{{name: "savepoint"}}, steps...)
+		b.Queue(`RELEASE SAVEPOINT batch_order`)
+		steps = append(steps, batchStep{name: "release savepoint"})
+
+		if err := execBatch(ctx, tx, b, steps); err != nil {
+			if _, rbErr := tx.Exec(ctx, `ROLLBACK TO SAVEPOINT batch_order`); rbErr != nil {
+				return nil, fmt.Errorf("failed to rollback to savepoint: %w", rbErr)
+			}
+			errs[i] = err
+			if !IsStaleWrite(err) {
+				failed[order.OrderUUID] = err
+			}
 		}
-	}(tx, ctx)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+
+	return errs, nil
+}
+
+// batchStep — запрос в пачке: название для ошибок и, если запрос что-то возвращает, куда это прочитать.
+// noRows возвращается вместо pgx.ErrNoRows, когда запрос ничего не вернул.
+type batchStep struct {
+	name   string
+	scan   []any
+	noRows error
+}
+
+// orderUpsertSet — поля orders, обновляемые при upsert заказа. Любое изменение увеличивает версию.
+const orderUpsertSet = `track_number=EXCLUDED.track_number,
+                                      entry=EXCLUDED.entry,
+                                      locale=EXCLUDED.locale,
+                                      internal_signature=EXCLUDED.internal_signature,
+                                      customer_id=EXCLUDED.customer_id,
+                                      delivery_service=EXCLUDED.delivery_service,
+                                      shardkey=EXCLUDED.shardkey,
+                                      sm_id=EXCLUDED.sm_id,
+                                      date_created=EXCLUDED.date_created,
+                                      oof_shard=EXCLUDED.oof_shard,
+                                      version=orders.version + 1`
+
+// orderWriteAllowed — условие, при котором входящий заказ incoming перезаписывает сохранённый stored.
+// Заказ с ожидаемой версией version (не 0) применяется, только если она совпадает с текущей;
+// заказ без версии — если его date_created новее (skip) или не старше (last_write_wins) сохранённого.
+// Аргументы — SQL-выражения: имена таблиц, параметры или колонки.
+func orderWriteAllowed(incoming, stored, version, policy string) string {
+	return fmt.Sprintf(`CASE WHEN %[3]s <> 0 THEN %[2]s.version = %[3]s
+                               WHEN %[4]s = '%[5]s' THEN %[1]s.date_created >= %[2]s.date_created
+                               ELSE %[1]s.date_created > %[2]s.date_created END`,
+		incoming, stored, version, policy, model.OutOfOrderLastWriteWins)
+}
 
+// queueOrder ставит в пачку все запросы сохранения заказа вместе с событием outbox
+// и ревизией и возвращает их шаги. Текущие статус и версия заказа читаются обратно в order.
+// Если существующий заказ не обновился по условию policy, пачка завершается ошибкой
+// и вызывающий откатывает её вместе с остальными запросами.
+func queueOrder(b *pgx.Batch, order *model.Order, policy model.OutOfOrderPolicy) ([]batchStep, error) {
 	// сохраняем orders
-	_, err = tx.Exec(
-		ctx,
+	b.Queue(
 		`INSERT INTO orders (order_uid, 
                     track_number, 
                     entry, 
@@ -103,25 +255,26 @@ func (p *Postgres) SaveUserData(ctx context.Context, order *model.Order) error {
                     sm_id, 
                     date_created, 
                     oof_shard) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-                    ON CONFLICT (order_uid) DO UPDATE SET track_number=EXCLUDED.track_number,
-                                                          entry=EXCLUDED.entry,
-                                                          locale=EXCLUDED.locale,
-                                                          internal_signature=EXCLUDED.internal_signature,
-                                                          customer_id=EXCLUDED.customer_id,
-                                                          delivery_service=EXCLUDED.delivery_service,
-                                                          shardkey=EXCLUDED.shardkey,
-                                                          sm_id=EXCLUDED.sm_id,
-                                                          date_created=EXCLUDED.date_created,
-                                                          oof_shard=EXCLUDED.oof_shard`,
+                    ON CONFLICT (order_uid) DO UPDATE SET `+orderUpsertSet+`
+                    WHERE `+orderWriteAllowed("EXCLUDED", "orders", "$12::bigint", "$13::text")+`
+                    RETURNING status, version`,
 		order.OrderUUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
 		order.CustomerID, order.DeliveryService, order.Shardkey, order.SmID, order.DateCreated, order.OOFShard,
+		order.Version, string(policy),
 	)
-	if err != nil {
-		return fmt.Errorf("failed to save order: %w", err)
+	// ожидаемая версия (If-Match) проверяется при любой политике; policy решает только за события без версии
+	staleErr := ErrVersionConflict
+	if order.Version == 0 {
+		staleErr = ErrStaleOrder
+	}
+
+	// событие для outbox — в той же транзакции, что и сам заказ
+	if err := queueOrderEvent(b, order); err != nil {
+		return nil, err
 	}
 
 	// сохраняем delivery
-	_, err = tx.Exec(ctx, `INSERT INTO delivery (order_uid, name, phone, zip, city, address, region, email)  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	b.Queue(`INSERT INTO delivery (order_uid, name, phone, zip, city, address, region, email)  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 ON CONFLICT (order_uid) DO UPDATE SET name=EXCLUDED.name,
                                       phone=EXCLUDED.phone,
                                       zip=EXCLUDED.zip,
@@ -132,12 +285,9 @@ ON CONFLICT (order_uid) DO UPDATE SET name=EXCLUDED.name,
 		order.OrderUUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip, order.Delivery.City,
 		order.Delivery.Address, order.Delivery.Region, order.Delivery.Email,
 	)
-	if err != nil {
-		return fmt.Errorf("failed to save delivery: %w", err)
-	}
 
 	// сохраняем payment
-	_, err = tx.Exec(ctx,
+	b.Queue(
 		`INSERT INTO payment (order_uid, transaction, request_id, currency, provider, amount, payment_dt, bank, delivery_cost, goods_total, custom_fee) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
         ON CONFLICT (order_uid) DO UPDATE SET
                                               transaction=EXCLUDED.transaction,
@@ -154,19 +304,90 @@ ON CONFLICT (order_uid) DO UPDATE SET name=EXCLUDED.name,
 		order.OrderUUID, order.Payment.Transaction, order.Payment.RequestId, order.Payment.Currency, order.Payment.Provider,
 		order.Payment.Amount, order.Payment.Payment, order.Payment.Bank, order.Payment.DeliveryCost, order.Payment.GoodsTotal,
 		order.Payment.CustomFee)
-	if err != nil {
-		return fmt.Errorf("failed to save payment: %w", err)
+
+	steps := []batchStep{
+		{name: "order", scan: []any{&order.Status, &order.Version}, noRows: staleErr},
+		{name: "order event"},
+		{name: "delivery"},
+		{name: "payment"},
+		{name: "old items"},
 	}
 
-	// сохраняем items
+	// сохраняем items: набор товаров заменяем целиком в рамках транзакции
+	b.Queue(`DELETE FROM items WHERE order_uid = $1`, order.OrderUUID)
+
 	for _, item := range order.Items {
-		_, err = tx.Exec(ctx,
+		b.Queue(
 			`INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
 			order.OrderUUID, item.ChrtID, item.TrackNumber, item.Price, item.Rid, item.Name, item.Sale, item.Size, item.TotalPrice, item.NmID, item.Brand, item.Status,
 		)
+		steps = append(steps, batchStep{name: fmt.Sprintf("item %d", item.ChrtID)})
 	}
 
-	return tx.Commit(ctx)
+	// снимок для истории изменений — после upsert заказа, который блокирует его строку
+	if err := queueOrderRevision(b, order); err != nil {
+		return nil, err
+	}
+	steps = append(steps, batchStep{name: "revision"})
+
+	// полнотекстовый индекс — после записи delivery и items
+	queueSearchVector(b, order)
+	steps = append(steps, batchStep{name: "search vector"})
+
+	return steps, nil
+}
+
+// execBatch отправляет пачку одним round-trip и возвращает первую ошибку с названием шага
+func execBatch(ctx context.Context, tx pgx.Tx, b *pgx.Batch, steps []batchStep) error {
+	br := tx.SendBatch(ctx, b)
+	for _, step := range steps {
+		var err error
+		if len(step.scan) > 0 {
+			err = br.QueryRow().Scan(step.scan...)
+			if step.noRows != nil && errors.Is(err, pgx.ErrNoRows) {
+				_ = br.Close()
+				return step.noRows
+			}
+		} else {
+			_, err = br.Exec()
+		}
+		if err != nil {
+			_ = br.Close()
+			return fmt.Errorf("failed to save %s: %w", step.name, err)
+		}
+	}
+	return br.Close()
+}
+
+// rollbackTimeout ограничивает откат, когда ctx вызывающего уже отменён
+const rollbackTimeout = 5 * time.Second
+
+// rollback откатывает транзакцию и после отмены ctx (клиент оборвал запрос, истёк таймаут):
+// с отменённым ctx pgx не отправит ROLLBACK и закроет соединение
+func (p *Postgres) rollback(ctx context.Context, tx pgx.Tx) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		p.log.Error("Rollback failed", sl.Err(err))
+	}
+}
+
+// GetOrderVersion читает текущую версию заказа из БД, минуя кеш
+func (p *Postgres) GetOrderVersion(ctx context.Context, orderUID string) (int64, error) {
+	if p.pool == nil {
+		return 0, fmt.Errorf("pool is nil")
+	}
+
+	var version int64
+	err := p.pool.QueryRow(ctx, `SELECT version FROM orders WHERE order_uid = $1`, orderUID).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrOrderNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get order version: %w", err)
+	}
+	return version, nil
 }
 
 func (p *Postgres) GetOrder(ctx context.Context, orderUID string) (*model.Order, error) {
@@ -177,7 +398,7 @@ func (p *Postgres) GetOrder(ctx context.Context, orderUID string) (*model.Order,
 	var order model.Order
 
 	// получаем Orders
-	err := p.pool.QueryRow(ctx, `SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard FROM orders WHERE order_uid = $1`,
+	err := p.pool.QueryRow(ctx, `SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard, status, version FROM orders WHERE order_uid = $1`,
 		orderUID).Scan(&order.OrderUUID,
 		&order.TrackNumber,
 		&order.Entry,
@@ -189,8 +410,13 @@ func (p *Postgres) GetOrder(ctx context.Context, orderUID string) (*model.Order,
 		&order.SmID,
 		&order.DateCreated,
 		&order.OOFShard,
+		&order.Status,
+		&order.Version,
 	)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
@@ -228,7 +454,7 @@ func (p *Postgres) GetOrder(ctx context.Context, orderUID string) (*model.Order,
 
 	// получаем Items
 	rows, err := p.pool.Query(ctx,
-		`SELECT chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status FROM items WHERE order_uid = $1`,
+		`SELECT chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status FROM items WHERE order_uid = $1 ORDER BY id`,
 		orderUID,
 	)
 	if err != nil {
@@ -264,34 +490,106 @@ func (p *Postgres) GetOrders(ctx context.Context) (map[string]*model.Order, erro
 		return nil, fmt.Errorf("pool is nil")
 	}
 
-	// Берём только order_uid, чтобы потом подтянуть весь заказ через GetOrder
-	rows, err := p.pool.Query(ctx, `SELECT order_uid FROM orders ORDER BY order_uid`)
+	orders := make(map[string]*model.Order)
+
+	// идём по order_uid страницами и догружаем каждую страницу пачкой
+	lastUID := ""
+	for {
+		uids, err := p.orderUIDsAfter(ctx, lastUID, ordersPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(uids) == 0 {
+			break
+		}
+
+		page, err := p.GetOrdersByUIDs(ctx, uids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get orders page: %w", err)
+		}
+		for _, order := range page {
+			orders[order.OrderUUID] = order
+		}
+
+		if len(uids) < ordersPageSize {
+			break
+		}
+		lastUID = uids[len(uids)-1]
+	}
+
+	return orders, nil
+}
+
+func (p *Postgres) orderUIDsAfter(ctx context.Context, after string, limit int) ([]string, error) {
+	rows, err := p.pool.Query(ctx, `SELECT order_uid FROM orders WHERE order_uid > $1 ORDER BY order_uid LIMIT $2`, after, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all order_uids: %w", err)
+		return nil, fmt.Errorf("failed to get order_uids: %w", err)
 	}
 	defer rows.Close()
 
-	orders := make(map[string]*model.Order)
-
+	uids := make([]string, 0, limit)
 	for rows.Next() {
 		var orderUID string
-
 		if err := rows.Scan(&orderUID); err != nil {
 			return nil, fmt.Errorf("failed to scan order_uid: %w", err)
 		}
+		uids = append(uids, orderUID)
+	}
 
-		// Загружаем полный заказ по UID
-		order, err := p.GetOrder(ctx, orderUID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get order %s: %w", orderUID, err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return uids, nil
+}
+
+// ScanRecentOrderUIDs потоково читает order_uid заказов, созданных не раньше since,
+// от новых к старым и отдаёт их в fn пачками по batchSize.
+// limit <= 0 означает без ограничения.
+func (p *Postgres) ScanRecentOrderUIDs(ctx context.Context, since time.Time, limit, batchSize int, fn func(uids []string) error) error {
+	if p.pool == nil {
+		return fmt.Errorf("pool is nil")
+	}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	var lim *int
+	if limit > 0 {
+		lim = &limit
+	}
+
+	rows, err := p.pool.Query(ctx,
+		`SELECT order_uid FROM orders WHERE date_created >= $1 ORDER BY date_created DESC, order_uid LIMIT $2`,
+		since.UTC(), lim,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query recent order_uids: %w", err)
+	}
+	defer rows.Close()
+
+	batch := make([]string, 0, batchSize)
+	for rows.Next() {
+		var orderUID string
+		if err := rows.Scan(&orderUID); err != nil {
+			return fmt.Errorf("failed to scan order_uid: %w", err)
 		}
 
-		orders[orderUID] = order
+		batch = append(batch, orderUID)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]string, 0, batchSize)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %w", err)
+		return fmt.Errorf("row iteration error: %w", err)
 	}
 
-	return orders, nil
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
 }
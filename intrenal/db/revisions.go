@@ -0,0 +1,97 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrRevisionNotFound = errors.New("order revision not found")
+
+// queueOrderRevision ставит в пачку снимок заказа. Ревизия добавляется, только если снимок
+// отличается от последнего. Должна идти после upsert заказа: блокировка строки orders
+// не даёт параллельным сохранениям получить один и тот же номер ревизии.
+func queueOrderRevision(b *pgx.Batch, order *model.Order) error {
+	data, err := orderSnapshot(order)
+	if err != nil {
+		return err
+	}
+
+	b.Queue(`INSERT INTO order_revisions (order_uid, revision, snapshot)
+SELECT $1, COALESCE(MAX(revision), 0) + 1, $2::jsonb
+FROM order_revisions
+WHERE order_uid = $1
+HAVING NOT EXISTS (SELECT 1 FROM order_revisions
+                   WHERE order_uid = $1 AND snapshot = $2::jsonb
+                     AND revision = (SELECT MAX(revision) FROM order_revisions WHERE order_uid = $1))`,
+		order.OrderUUID, data,
+	)
+	return nil
+}
+
+// orderSnapshot — содержимое заказа для ревизии: статус и версия в него не входят
+func orderSnapshot(order *model.Order) ([]byte, error) {
+	snapshot := *order
+	snapshot.Status = ""
+	snapshot.Version = 0
+
+	data, err := json.Marshal(&snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode order snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// GetOrderRevisions возвращает все ревизии заказа по возрастанию
+func (p *Postgres) GetOrderRevisions(ctx context.Context, orderUID string) ([]model.OrderRevision, error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+
+	rows, err := p.pool.Query(ctx,
+		`SELECT order_uid, revision, created_at, snapshot FROM order_revisions WHERE order_uid = $1 ORDER BY revision`, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order revisions: %w", err)
+	}
+
+	revisions, err := pgx.CollectRows(rows, scanRevision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan order revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (p *Postgres) GetOrderRevision(ctx context.Context, orderUID string, revision int) (*model.OrderRevision, error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+
+	rows, err := p.pool.Query(ctx,
+		`SELECT order_uid, revision, created_at, snapshot FROM order_revisions WHERE order_uid = $1 AND revision = $2`, orderUID, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order revision: %w", err)
+	}
+
+	rev, err := pgx.CollectExactlyOneRow(rows, scanRevision)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to scan order revision: %w", err)
+	}
+
+	return &rev, nil
+}
+
+func scanRevision(row pgx.CollectableRow) (model.OrderRevision, error) {
+	var rev model.OrderRevision
+	if err := row.Scan(&rev.OrderUID, &rev.Revision, &rev.CreatedAt, &rev.Order); err != nil {
+		return rev, err
+	}
+	return rev, nil
+}
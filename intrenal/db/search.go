@@ -0,0 +1,78 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+	"fmt"
+	"strings"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern — шаблон ILIKE для поиска подстроки; спецсимволы LIKE в запросе экранируются
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// orderSearchWhere переводит ключи поиска в условия над orders o и delivery d
+func orderSearchWhere(s model.OrderSearch) *whereBuilder {
+	w := &whereBuilder{}
+
+	if s.TrackNumber != "" {
+		w.add("o.track_number = ?", s.TrackNumber)
+	}
+	if s.CustomerID != "" {
+		w.add("o.customer_id = ?", s.CustomerID)
+	}
+	if s.Phone != "" {
+		w.add("d.phone = ?", s.Phone)
+	}
+	if s.Email != "" {
+		w.add("d.email ILIKE ?", containsPattern(s.Email))
+	}
+	if s.Name != "" {
+		w.add("d.name ILIKE ?", containsPattern(s.Name))
+	}
+	// условия по товарам — для одного и того же товара
+	if s.NmID != nil && s.Brand != "" {
+		w.add("EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.nm_id = ? AND i.brand ILIKE ?)",
+			*s.NmID, containsPattern(s.Brand))
+	} else if s.NmID != nil {
+		w.add("EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.nm_id = ?)", *s.NmID)
+	} else if s.Brand != "" {
+		w.add("EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.brand ILIKE ?)", containsPattern(s.Brand))
+	}
+
+	return w
+}
+
+// SearchOrders ищет заказы по ключам s, новые заказы идут первыми
+func (p *Postgres) SearchOrders(ctx context.Context, s model.OrderSearch) ([]*model.Order, error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+
+	w := orderSearchWhere(s)
+	query := `SELECT o.order_uid FROM orders o JOIN delivery d ON d.order_uid = o.order_uid` + w.String() +
+		fmt.Sprintf(" ORDER BY o.date_created DESC, o.order_uid LIMIT %d", s.Limit)
+
+	rows, err := p.pool.Query(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search orders: %w", err)
+	}
+	defer rows.Close()
+
+	uids := make([]string, 0, s.Limit)
+	for rows.Next() {
+		var orderUID string
+		if err := rows.Scan(&orderUID); err != nil {
+			return nil, fmt.Errorf("failed to scan order_uid: %w", err)
+		}
+		uids = append(uids, orderUID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return p.GetOrdersByUIDs(ctx, uids)
+}
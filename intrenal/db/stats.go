@@ -0,0 +1,89 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// statsDimensions — SQL-выражения измерений статистики над orders o и payment p.
+// date_created — TIMESTAMP без зоны, уже хранится в UTC, поэтому день берётся как есть.
+var statsDimensions = map[string]string{
+	model.StatsByDay:             `to_char(o.date_created, 'YYYY-MM-DD')`,
+	model.StatsByCurrency:        `p.currency`,
+	model.StatsByProvider:        `p.provider`,
+	model.StatsByBank:            `p.bank`,
+	model.StatsByDeliveryService: `o.delivery_service`,
+}
+
+// ValidStatsDimension сообщает, можно ли группировать статистику по dim
+func ValidStatsDimension(dim string) bool {
+	_, ok := statsDimensions[dim]
+	return ok
+}
+
+// OrderStats считает количество заказов и суммы платежей по группам агрегатами SQL
+func (p *Postgres) OrderStats(ctx context.Context, q model.StatsQuery) ([]model.StatsRow, error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+
+	exprs := make([]string, 0, len(q.GroupBy))
+	for _, dim := range q.GroupBy {
+		expr, ok := statsDimensions[dim]
+		if !ok {
+			return nil, fmt.Errorf("unknown stats dimension %q", dim)
+		}
+		exprs = append(exprs, expr)
+	}
+
+	w := &whereBuilder{}
+	w.add("o.date_created >= ?", q.DateFrom.UTC())
+	w.add("o.date_created < ?", q.DateTo.UTC())
+
+	selectDims, groupBy := "", ""
+	if len(exprs) > 0 {
+		selectDims = strings.Join(exprs, ", ") + ", "
+		positions := make([]string, len(exprs))
+		for i := range exprs {
+			positions[i] = fmt.Sprint(i + 1)
+		}
+		groupBy = " GROUP BY " + strings.Join(positions, ", ") + " ORDER BY " + strings.Join(positions, ", ")
+	}
+
+	query := `SELECT ` + selectDims + `count(*), coalesce(sum(p.amount), 0), coalesce(sum(p.delivery_cost), 0),
+       coalesce(sum(p.custom_fee), 0), coalesce(avg(p.amount), 0)::float8
+FROM orders o JOIN payment p ON p.order_uid = o.order_uid` + w.String() + groupBy
+
+	rows, err := p.pool.Query(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order stats: %w", err)
+	}
+	defer rows.Close()
+
+	var result []model.StatsRow
+	for rows.Next() {
+		values := make([]string, len(exprs))
+		row := model.StatsRow{Group: make(map[string]string, len(exprs))}
+
+		dest := make([]any, 0, len(exprs)+5)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &row.Orders, &row.Amount, &row.DeliveryCost, &row.CustomFee, &row.AvgAmount)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan order stats: %w", err)
+		}
+		for i, dim := range q.GroupBy {
+			row.Group[dim] = values[i]
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrVersionConflict — заказ изменился после того, как клиент прочитал ожидаемую версию
+	ErrVersionConflict = errors.New("order version conflict")
+	// ErrStaleOrder — заказ без версии не новее сохранённого по date_created (с учётом policy)
+	ErrStaleOrder = errors.New("order is older than stored")
+)
+
+// IsStaleWrite сообщает, что запись отклонена как устаревшая и повторять её бессмысленно
+func IsStaleWrite(err error) bool {
+	return errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrStaleOrder)
+}
+
+// UpdateOrderStatus переводит заказ в статус to под блокировкой строки заказа.
+// expectedVersion, если не 0, должна совпадать с текущей версией, иначе ErrVersionConflict.
+// allowed проверяет переход из текущего статуса; если заказ уже в статусе to, ничего не пишется.
+// Переход сохраняется в order_status_history и в outbox одной транзакцией и увеличивает версию заказа.
+func (p *Postgres) UpdateOrderStatus(ctx context.Context, orderUID string, to model.OrderStatus, source string, expectedVersion int64, allowed func(from, to model.OrderStatus) error) (*model.StatusChange, error) {
+	if p.pool == nil {
+		return nil, fmt.Errorf("pool is nil")
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer p.rollback(ctx, tx)
+
+	change := &model.StatusChange{OrderUID: orderUID, To: to, Source: source}
+
+	err = tx.QueryRow(ctx, `SELECT status, version, now() FROM orders WHERE order_uid = $1 FOR UPDATE`, orderUID).
+		Scan(&change.From, &change.Version, &change.ChangedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to lock order: %w", err)
+	}
+
+	if expectedVersion != 0 && change.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+	if change.From == to {
+		return change, nil
+	}
+	if err := allowed(change.From, to); err != nil {
+		return nil, err
+	}
+
+	change.Version++
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode status event: %w", err)
+	}
+
+	b := &pgx.Batch{}
+	b.Queue(`UPDATE orders SET status = $2, version = $3 WHERE order_uid = $1`, orderUID, to, change.Version)
+	b.Queue(`INSERT INTO order_status_history (order_uid, from_status, to_status, source, changed_at) VALUES ($1, $2, $3, $4, $5)`,
+		orderUID, change.From, to, source, change.ChangedAt)
+	b.Queue(`INSERT INTO order_events (order_uid, event_type, payload) VALUES ($1, $2, $3)`,
+		orderUID, model.EventOrderStatusChanged, payload)
+
+	steps := []batchStep{{name: "order status"}, {name: "status history"}, {name: "status event"}}
+	if err := execBatch(ctx, tx, b, steps); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit status change: %w", err)
+	}
+
+	return change, nil
+}
@@ -0,0 +1,216 @@
+package export
+
+import (
+	model "WB_Service/intrenal/models"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Форматы выгрузки
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// Writer пишет заказы в поток по одному. Close дописывает хвост формата, но не закрывает сам поток.
+type Writer interface {
+	Write(order *model.Order) error
+	Close() error
+}
+
+// ValidFormat сообщает, поддерживается ли формат выгрузки
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatCSV, FormatNDJSON, FormatXLSX:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewWriter создаёт Writer формата format поверх w
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ContentType возвращает MIME-тип формата
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// column — колонка табличной выгрузки. Для табличных форматов заказ разворачивается
+// в строку на каждый товар: поля заказа, delivery и payment повторяются.
+type column struct {
+	name  string
+	value func(o *model.Order, it *model.Item) any
+}
+
+func orderCol(name string, value func(o *model.Order) any) column {
+	return column{name: name, value: func(o *model.Order, _ *model.Item) any { return value(o) }}
+}
+
+func itemCol(name string, value func(it *model.Item) any) column {
+	return column{name: name, value: func(_ *model.Order, it *model.Item) any {
+		if it == nil {
+			return nil
+		}
+		return value(it)
+	}}
+}
+
+var columns = []column{
+	orderCol("order_uid", func(o *model.Order) any { return o.OrderUUID }),
+	orderCol("track_number", func(o *model.Order) any { return o.TrackNumber }),
+	orderCol("entry", func(o *model.Order) any { return o.Entry }),
+	orderCol("locale", func(o *model.Order) any { return o.Locale }),
+	orderCol("internal_signature", func(o *model.Order) any { return o.InternalSignature }),
+	orderCol("customer_id", func(o *model.Order) any { return o.CustomerID }),
+	orderCol("delivery_service", func(o *model.Order) any { return o.DeliveryService }),
+	orderCol("shardkey", func(o *model.Order) any { return o.Shardkey }),
+	orderCol("sm_id", func(o *model.Order) any { return o.SmID }),
+	orderCol("date_created", func(o *model.Order) any { return o.DateCreated }),
+	orderCol("oof_shard", func(o *model.Order) any { return o.OOFShard }),
+	orderCol("status", func(o *model.Order) any { return string(o.Status) }),
+	orderCol("version", func(o *model.Order) any { return o.Version }),
+
+	orderCol("delivery_name", func(o *model.Order) any { return o.Delivery.Name }),
+	orderCol("delivery_phone", func(o *model.Order) any { return o.Delivery.Phone }),
+	orderCol("delivery_zip", func(o *model.Order) any { return o.Delivery.Zip }),
+	orderCol("delivery_city", func(o *model.Order) any { return o.Delivery.City }),
+	orderCol("delivery_address", func(o *model.Order) any { return o.Delivery.Address }),
+	orderCol("delivery_region", func(o *model.Order) any { return o.Delivery.Region }),
+	orderCol("delivery_email", func(o *model.Order) any { return o.Delivery.Email }),
+
+	orderCol("payment_transaction", func(o *model.Order) any { return o.Payment.Transaction }),
+	orderCol("payment_request_id", func(o *model.Order) any { return o.Payment.RequestId }),
+	orderCol("payment_currency", func(o *model.Order) any { return o.Payment.Currency }),
+	orderCol("payment_provider", func(o *model.Order) any { return o.Payment.Provider }),
+	orderCol("payment_amount", func(o *model.Order) any { return o.Payment.Amount }),
+	orderCol("payment_dt", func(o *model.Order) any { return o.Payment.Payment }),
+	orderCol("payment_bank", func(o *model.Order) any { return o.Payment.Bank }),
+	orderCol("payment_delivery_cost", func(o *model.Order) any { return o.Payment.DeliveryCost }),
+	orderCol("payment_goods_total", func(o *model.Order) any { return o.Payment.GoodsTotal }),
+	orderCol("payment_custom_fee", func(o *model.Order) any { return o.Payment.CustomFee }),
+
+	itemCol("item_chrt_id", func(it *model.Item) any { return it.ChrtID }),
+	itemCol("item_track_number", func(it *model.Item) any { return it.TrackNumber }),
+	itemCol("item_price", func(it *model.Item) any { return it.Price }),
+	itemCol("item_rid", func(it *model.Item) any { return it.Rid }),
+	itemCol("item_name", func(it *model.Item) any { return it.Name }),
+	itemCol("item_sale", func(it *model.Item) any { return it.Sale }),
+	itemCol("item_size", func(it *model.Item) any { return it.Size }),
+	itemCol("item_total_price", func(it *model.Item) any { return it.TotalPrice }),
+	itemCol("item_nm_id", func(it *model.Item) any { return it.NmID }),
+	itemCol("item_brand", func(it *model.Item) any { return it.Brand }),
+	itemCol("item_status", func(it *model.Item) any { return it.Status }),
+}
+
+// Columns возвращает названия колонок табличной выгрузки
+func Columns() []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// flatten разворачивает заказ в строки по товарам; заказ без товаров даёт одну строку
+func flatten(order *model.Order, row func(values []any) error) error {
+	values := make([]any, len(columns))
+	fill := func(it *model.Item) error {
+		for i, c := range columns {
+			values[i] = c.value(order, it)
+		}
+		return row(values)
+	}
+
+	if len(order.Items) == 0 {
+		return fill(nil)
+	}
+	for i := range order.Items {
+		if err := fill(&order.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	if err := cw.w.Write(Columns()); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (c *csvWriter) Write(order *model.Order) error {
+	return flatten(order, func(values []any) error {
+		for i, v := range values {
+			c.record[i] = formatValue(v)
+		}
+		return c.w.Write(c.record)
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ndjsonWriter пишет заказ целиком, без разворачивания вложенных структур
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(order *model.Order) error {
+	return n.enc.Encode(order)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	model "WB_Service/intrenal/models"
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Минимальная книга xlsx из одного листа. Лист пишется потоком прямо в zip,
+// строки хранятся inline, поэтому sharedStrings и стили не нужны.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="orders" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+
+	for _, f := range []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	} {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", f.name, err)
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+
+	// лист — последний файл архива, его можно писать до конца выгрузки
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sheet: %w", err)
+	}
+
+	x := &xlsxWriter{zw: zw, sheet: bufio.NewWriter(sw)}
+	_, _ = x.sheet.WriteString(xlsxSheetHeader)
+
+	header := make([]any, len(columns))
+	for i, name := range Columns() {
+		header[i] = name
+	}
+	if err := x.writeRow(header); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+func (x *xlsxWriter) Write(order *model.Order) error {
+	return flatten(order, x.writeRow)
+}
+
+func (x *xlsxWriter) writeRow(values []any) error {
+	_, _ = x.sheet.WriteString("<row>")
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			_, _ = x.sheet.WriteString("<c/>")
+		case int:
+			_, _ = x.sheet.WriteString(`<c><v>` + strconv.Itoa(v) + `</v></c>`)
+		case int64:
+			_, _ = x.sheet.WriteString(`<c><v>` + strconv.FormatInt(v, 10) + `</v></c>`)
+		default:
+			_, _ = x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(x.sheet, []byte(formatValue(v))); err != nil {
+				return err
+			}
+			_, _ = x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString("</row>")
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	_, _ = x.sheet.WriteString(xlsxSheetFooter)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/IBM/sarama"
+)
+
+var errNotReady = errors.New("not ready yet")
+
+// Flag — готовность, которую выставляет сам сервис (например, окончание прогрева кеша)
+func Flag(ready *atomic.Bool) CheckFunc {
+	return func(context.Context) error {
+		if !ready.Load() {
+			return errNotReady
+		}
+		return nil
+	}
+}
+
+// KafkaTopic проверяет, что брокеры отвечают и у топика есть лидеры партиций
+func KafkaTopic(client sarama.Client, topic string) CheckFunc {
+	return func(ctx context.Context) error {
+		return withContext(ctx, func() error {
+			if err := client.RefreshMetadata(topic); err != nil {
+				return fmt.Errorf("refresh metadata: %w", err)
+			}
+			partitions, err := client.Partitions(topic)
+			if err != nil {
+				return err
+			}
+			for _, p := range partitions {
+				if _, err := client.Leader(topic, p); err != nil {
+					return fmt.Errorf("partition %d has no leader: %w", p, err)
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// KafkaGroup проверяет доступность координатора consumer group
+func KafkaGroup(client sarama.Client, groupID string) CheckFunc {
+	return func(ctx context.Context) error {
+		return withContext(ctx, func() error {
+			if err := client.RefreshCoordinator(groupID); err != nil {
+				return fmt.Errorf("refresh coordinator: %w", err)
+			}
+			_, err := client.Coordinator(groupID)
+			return err
+		})
+	}
+}
+
+// withContext выполняет блокирующий вызов без поддержки контекста, не дольше чем живёт ctx
+func withContext(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
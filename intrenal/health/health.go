@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckFunc проверяет одну зависимость; nil — компонент готов
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+const (
+	StatusOK       = "ok"
+	StatusFail     = "fail"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusShutdown = "shutting_down"
+)
+
+// Checker отдаёт /healthz и /readyz. Проверки готовности выполняются параллельно с общим таймаутом.
+type Checker struct {
+	mu       sync.RWMutex
+	checks   []check
+	timeout  time.Duration
+	shutdown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+	}
+}
+
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// SetShuttingDown переводит сервис в неготовое состояние, чтобы балансировщик перестал слать трафик
+func (c *Checker) SetShuttingDown() {
+	c.shutdown.Store(true)
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{
+		Status:     StatusReady,
+		Components: make(map[string]ComponentStatus, len(checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, ch := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := ch.fn(ctx)
+			status := ComponentStatus{
+				Status:    StatusOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				status.Status = StatusFail
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			report.Components[ch.name] = status
+			if err != nil {
+				report.Status = StatusNotReady
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if c.shutdown.Load() {
+		report.Status = StatusShutdown
+	}
+
+	return report
+}
+
+// LivenessHandler GET /healthz — процесс жив и обрабатывает запросы
+func (c *Checker) LivenessHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// ReadinessHandler GET /readyz — все зависимости доступны и сервис не останавливается
+func (c *Checker) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+
+	code := http.StatusOK
+	if report.Status != StatusReady {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, report)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,30 @@
+package serv
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const maxInvalidateBodyBytes = 4 << 20
+
+// InvalidateCacheRequest — тело POST /admin/cache/invalidate
+type InvalidateCacheRequest struct {
+	OrderUIDs []string `json:"order_uids"`
+}
+
+// InvalidateCacheHandler POST /admin/cache/invalidate — убирает заказы из кеша этого экземпляра,
+// если их изменили в БД в обход сервиса (cmd/import)
+func (h *Handler) InvalidateCacheHandler(w http.ResponseWriter, r *http.Request) {
+	var req InvalidateCacheRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxInvalidateBodyBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.service.InvalidateOrders(req.OrderUIDs)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "ok",
+		"invalidated": len(req.OrderUIDs),
+	})
+}
@@ -0,0 +1,90 @@
+package serv
+
+import (
+	"WB_Service/intrenal/kafka/dlq"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultDLQListLimit = 100
+
+type DLQAdmin interface {
+	List(ctx context.Context, limit int) ([]dlq.Message, error)
+	Redrive(ctx context.Context, partition int32, offset int64) (int32, int64, error)
+}
+
+type DLQHandler struct {
+	dlq DLQAdmin
+}
+
+func NewDLQHandler(dlq DLQAdmin) *DLQHandler {
+	return &DLQHandler{
+		dlq: dlq,
+	}
+}
+
+// ListHandler GET /admin/dlq?limit= — последние сообщения из каждой партиции DLQ (limit не больше dlq.MaxListLimit)
+func (h *DLQHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	limit := defaultDLQListLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	messages, err := h.dlq.List(ctx, limit)
+	if err != nil {
+		http.Error(w, "failed to read dlq: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(messages)
+}
+
+// RedriveHandler POST /admin/dlq/{partition}/{offset}/redrive — вернуть сообщение в основной топик
+func (h *DLQHandler) RedriveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	partition, err := strconv.ParseInt(chi.URLParam(r, "partition"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid partition", http.StatusBadRequest)
+		return
+	}
+	offset, err := strconv.ParseInt(chi.URLParam(r, "offset"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid offset", http.StatusBadRequest)
+		return
+	}
+
+	newPartition, newOffset, err := h.dlq.Redrive(ctx, int32(partition), offset)
+	if errors.Is(err, dlq.ErrMessageNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to redrive message: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"status":    "ok",
+		"partition": newPartition,
+		"offset":    newOffset,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
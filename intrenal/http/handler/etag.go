@@ -0,0 +1,29 @@
+package serv
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag заказа — его версия: "3"
+func formatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion разбирает If-Match. 0 — заголовка нет или он равен *, проверять версию не нужно.
+// Слабые ETag (W/"3") принимаются так же, как сильные.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(v, "W/"), `"`)
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid If-Match %q: expected order version ETag", v)
+	}
+	return version, nil
+}
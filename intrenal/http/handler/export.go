@@ -0,0 +1,80 @@
+package serv
+
+import (
+	"WB_Service/intrenal/export"
+	"WB_Service/intrenal/lib/sl"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ExportHandler GET /orders/export?format=csv|ndjson|xlsx&<фильтры листинга>
+// Заказы идут потоком из курсора Postgres; разрыв соединения клиентом отменяет запрос к БД.
+func (h *Handler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if !export.ValidFormat(format) {
+		http.Error(w, fmt.Sprintf("unknown export format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// выгрузка может идти дольше WriteTimeout сервера
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	// заголовки — до создания writer: он может сразу записать начало файла
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="orders-%s.%s"`, time.Now().UTC().Format("20060102-150405"), format))
+
+	out := &streamWriter{w: w}
+	ew, err := export.NewWriter(format, out)
+	if err == nil {
+		err = h.service.ExportOrders(r.Context(), filter, ew.Write)
+	}
+	if err == nil {
+		err = ew.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	canceled := errors.Is(err, context.Canceled)
+	if !canceled {
+		h.log.Error("order export aborted", slog.String("format", format), sl.Err(err))
+	}
+
+	if !out.started {
+		// в ответ ещё ничего не ушло — можно честно ответить ошибкой
+		if !canceled {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	// заголовки уже отправлены: рвём соединение, чтобы клиент не принял обрезанный файл за полный
+	panic(http.ErrAbortHandler)
+}
+
+// streamWriter запоминает, дошли ли до ответа первые байты выгрузки
+type streamWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		s.started = true
+	}
+	return s.w.Write(p)
+}
@@ -1,10 +1,15 @@
 package serv
 
 import (
+	"WB_Service/intrenal/db"
 	model "WB_Service/intrenal/models"
+	"WB_Service/intrenal/validation"
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/IBM/sarama"
+	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -13,19 +18,36 @@ import (
 
 type OrderService interface {
 	GetOrder(ctx context.Context, orderUID string) (*model.Order, error)
+	OrderVersion(ctx context.Context, orderUID string) (int64, error)
 	GetOrders(ctx context.Context) (map[string]*model.Order, error)
+	ListOrders(ctx context.Context, filter model.OrderFilter) (*model.OrderPage, error)
+	SearchOrders(ctx context.Context, search model.OrderSearch) ([]*model.Order, error)
+	ExportOrders(ctx context.Context, filter model.OrderFilter, fn func(*model.Order) error) error
 	SaveOrder(ctx context.Context, order *model.Order) error
+	SaveOrders(ctx context.Context, orders []*model.Order) ([]error, error)
+	ChangeOrderStatus(ctx context.Context, orderUID string, to model.OrderStatus, source string, expectedVersion int64) (*model.StatusChange, error)
+	OrderRevisions(ctx context.Context, orderUID string) ([]model.OrderRevision, error)
+	DiffOrderRevisions(ctx context.Context, orderUID string, from, to int) ([]model.FieldChange, error)
+	InvalidateOrders(orderUIDs []string)
 }
 
 type Handler struct {
-	service  OrderService
-	producer sarama.SyncProducer
+	service     OrderService
+	producer    sarama.SyncProducer
+	topic       string
+	validator   *validation.Validator
+	idempotency IdempotencyStore
+	log         *slog.Logger
 }
 
-func NewHandler(service OrderService, producer sarama.SyncProducer) *Handler {
+func NewHandler(service OrderService, producer sarama.SyncProducer, topic string, validator *validation.Validator, idempotency IdempotencyStore, log *slog.Logger) *Handler {
 	return &Handler{
-		service:  service,
-		producer: producer,
+		service:     service,
+		producer:    producer,
+		topic:       topic,
+		validator:   validator,
+		idempotency: idempotency,
+		log:         log,
 	}
 }
 
@@ -49,7 +71,7 @@ func (h *Handler) GetOrderHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	order, err := h.service.GetOrder(ctx, orderUID)
-	if err != nil {
+	if err != nil && !errors.Is(err, db.ErrOrderNotFound) {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -60,57 +82,129 @@ func (h *Handler) GetOrderHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if order.Version > 0 {
+		w.Header().Set("ETag", formatETag(order.Version))
+	}
 	_ = json.NewEncoder(w).Encode(order)
 }
 
-// GetOrdersHandler GetOrders GET /orders
+// GetOrdersHandler GetOrders GET /orders?limit=&cursor=&<фильтры>
 func (h *Handler) GetOrdersHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	orders, err := h.service.GetOrders(ctx)
+	filter, err := parseOrderFilter(r.URL.Query())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// превращаем map[string]*Order в slice, чтобы красиво вернуть JSON
-	resp := make([]*model.Order, 0, len(orders))
-	for _, o := range orders {
-		resp = append(resp, o)
+	page, err := h.service.ListOrders(ctx, filter)
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+// SearchOrdersHandler GET /orders/search?track_number=&customer_id=&phone=&email=&name=&nm_id=&brand=&limit=
+func (h *Handler) SearchOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	search, err := parseOrderSearch(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	orders, err := h.service.SearchOrders(ctx, search)
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if orders == nil {
+		orders = []*model.Order{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"orders": orders})
 }
 
-// SaveOrderHandler PublishOrderHandler принимает JSON заказа и отправляет в Kafka
+// SaveOrderHandler PublishOrderHandler принимает JSON заказа и отправляет в Kafka.
+// С заголовком Idempotency-Key повторный запрос с тем же телом получает сохранённый ответ.
+// С If-Match заказ принимается, только если его текущая версия совпадает; версия уходит
+// в сообщении, и consumer повторно проверяет её при сохранении.
 func (h *Handler) SaveOrderHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxOrderBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var order model.Order
-	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+	if err := json.Unmarshal(body, &order); err != nil {
 		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	// проверяем до отправки в Kafka теми же правилами, что и consumer
+	warnings, err := h.validator.Validate(&order)
+	if err != nil {
+		if vErr, ok := validation.AsError(err); ok {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"error":  "validation failed",
+				"fields": vErr.Fields,
+			})
+			return
+		}
+		http.Error(w, "failed to validate order: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	expected, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key := r.Header.Get(idempotencyKeyHeader)
+	if key != "" {
+		if done := h.reserveIdempotencyKey(ctx, w, key, body); done {
+			return
+		}
+	}
+
+	if expected != 0 {
+		if ok := h.checkVersion(ctx, w, order.OrderUUID, expected); !ok {
+			h.releaseIdempotencyKey(ctx, key)
+			return
+		}
+		order.Version = expected
+	}
+
 	// сериализуем обратно в []byte
 	data, err := json.Marshal(order)
 	if err != nil {
+		h.releaseIdempotencyKey(ctx, key)
 		http.Error(w, "failed to encode order: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// создаём Kafka сообщение
 	msg := &sarama.ProducerMessage{
-		Topic: "orders",
+		Topic: h.topic,
 		Value: sarama.ByteEncoder(data),
 		Key:   sarama.StringEncoder(order.OrderUUID),
 	}
 
 	partition, offset, err := h.producer.SendMessage(msg)
 	if err != nil {
+		h.releaseIdempotencyKey(ctx, key)
 		http.Error(w, "failed to send to Kafka: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -122,8 +216,40 @@ func (h *Handler) SaveOrderHandler(w http.ResponseWriter, r *http.Request) {
 		"partition": partition,
 		"offset":    offset,
 	}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+
+	respBody, _ := json.Marshal(resp)
+	if key != "" {
+		h.completeIdempotencyKey(ctx, key, respBody)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+	_, _ = w.Write(append(respBody, '\n'))
+}
 
-	_ = ctx // пока не используем, но можно, например, для логов
+// checkVersion отвечает 412, если заказа нет или его версия в БД не равна expected.
+// Это ранний ответ клиенту; окончательно версию проверяет upsert при сохранении.
+func (h *Handler) checkVersion(ctx context.Context, w http.ResponseWriter, orderUID string, expected int64) bool {
+	current, err := h.service.OrderVersion(ctx, orderUID)
+	switch {
+	case errors.Is(err, db.ErrOrderNotFound):
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "order not found"})
+		return false
+	case err != nil:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return false
+	case current != expected:
+		w.Header().Set("ETag", formatETag(current))
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": db.ErrVersionConflict.Error()})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
 }
@@ -0,0 +1,85 @@
+package serv
+
+import (
+	"WB_Service/intrenal/lib/sl"
+	model "WB_Service/intrenal/models"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+	maxOrderBodyBytes    = 1 << 20
+
+	// на запись ответа и снятие ключа отдельное время: к этому моменту таймаут запроса может истечь
+	idempotencyStoreTimeout = 2 * time.Second
+)
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, key, requestHash string) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, response []byte) error
+	Release(ctx context.Context, key string) error
+}
+
+// reserveIdempotencyKey занимает ключ за текущим запросом.
+// true — ответ уже записан (повтор, конфликт или ошибка) и обработку нужно прекратить.
+func (h *Handler) reserveIdempotencyKey(ctx context.Context, w http.ResponseWriter, key string, body []byte) bool {
+	if len(key) > maxIdempotencyKeyLen {
+		http.Error(w, idempotencyKeyHeader+" is too long", http.StatusBadRequest)
+		return true
+	}
+
+	sum := sha256.Sum256(bytes.TrimSpace(body))
+	hash := hex.EncodeToString(sum[:])
+
+	record, err := h.idempotency.Reserve(ctx, key, hash)
+	if err != nil {
+		http.Error(w, "failed to check idempotency key", http.StatusInternalServerError)
+		return true
+	}
+	if record == nil {
+		return false
+	}
+
+	switch {
+	case record.RequestHash != hash:
+		http.Error(w, idempotencyKeyHeader+" was already used with a different request body", http.StatusConflict)
+	case record.Response == nil:
+		http.Error(w, "request with this "+idempotencyKeyHeader+" is still in progress", http.StatusConflict)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Idempotent-Replayed", "true")
+		_, _ = w.Write(append(record.Response, '\n'))
+	}
+	return true
+}
+
+// completeIdempotencyKey сохраняет ответ, даже если запрос уже отменён: сообщение отправлено,
+// и повтор должен получить этот ответ, а не 409
+func (h *Handler) completeIdempotencyKey(ctx context.Context, key string, response []byte) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+	defer cancel()
+
+	if err := h.idempotency.Complete(ctx, key, response); err != nil {
+		h.log.Error("failed to store idempotent response", slog.String("key", key), sl.Err(err))
+	}
+}
+
+func (h *Handler) releaseIdempotencyKey(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+	defer cancel()
+
+	if err := h.idempotency.Release(ctx, key); err != nil {
+		h.log.Error("failed to release idempotency key", slog.String("key", key), sl.Err(err))
+	}
+}
@@ -0,0 +1,123 @@
+package serv
+
+import (
+	"WB_Service/intrenal/importer"
+	"WB_Service/intrenal/lib/sl"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// максимальный размер файла импорта через HTTP; большие дампы грузятся через cmd/import
+const maxImportBodyBytes = 256 << 20
+
+type Importer interface {
+	Import(ctx context.Context, r io.Reader, opts importer.Options) (*importer.Report, error)
+}
+
+type ImportHandler struct {
+	importer Importer
+	log      *slog.Logger
+}
+
+func NewImportHandler(importer Importer, log *slog.Logger) *ImportHandler {
+	return &ImportHandler{
+		importer: importer,
+		log:      log,
+	}
+}
+
+// ImportOrdersHandler POST /orders/import?format=ndjson|csv&dry_run=&start_line=&chunk_size=
+// Тело — файл целиком; в ответ отчёт с ошибками по строкам.
+func (h *ImportHandler) ImportOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseImportOptions(r.URL.Query(), r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// импорт может идти дольше таймаутов сервера
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+	report, err := h.importer.Import(r.Context(), body, opts)
+	if err == nil {
+		writeJSON(w, http.StatusOK, report)
+		return
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	h.log.Error("order import failed", slog.String("format", opts.Format), sl.Err(err))
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case report == nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &tooLarge):
+		writeJSON(w, http.StatusRequestEntityTooLarge, report)
+	case errors.Is(err, importer.ErrSave):
+		writeJSON(w, http.StatusInternalServerError, report)
+	default:
+		// файл не дочитан из-за синтаксической ошибки; сохранённое до неё видно в отчёте
+		writeJSON(w, http.StatusUnprocessableEntity, report)
+	}
+}
+
+func parseImportOptions(q url.Values, contentType string) (importer.Options, error) {
+	var opts importer.Options
+
+	opts.Format = q.Get("format")
+	if opts.Format == "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		switch mediaType {
+		case "text/csv":
+			opts.Format = importer.FormatCSV
+		default:
+			opts.Format = importer.FormatNDJSON
+		}
+	}
+	if opts.Format != importer.FormatNDJSON && opts.Format != importer.FormatCSV {
+		return opts, fmt.Errorf("invalid format %q", opts.Format)
+	}
+
+	if v := q.Get("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid dry_run %q", v)
+		}
+		opts.DryRun = dryRun
+	}
+
+	var err error
+	if opts.StartLine, err = parseNonNegative(q, "start_line"); err != nil {
+		return opts, err
+	}
+	if opts.ChunkSize, err = parseNonNegative(q, "chunk_size"); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+func parseNonNegative(q url.Values, name string) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return n, nil
+}
@@ -0,0 +1,145 @@
+package serv
+
+import (
+	model "WB_Service/intrenal/models"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// parseOrderFilter разбирает параметры листинга:
+// limit, cursor, customer_id, delivery_service, currency, provider, bank,
+// date_from, date_to (RFC3339 или YYYY-MM-DD), amount_min, amount_max, q (полнотекстовый поиск).
+// date_from включается в выборку, date_to в RFC3339 — нет; date_to в виде YYYY-MM-DD включает весь этот день.
+func parseOrderFilter(q url.Values) (model.OrderFilter, error) {
+	f := model.OrderFilter{
+		CustomerID:      q.Get("customer_id"),
+		DeliveryService: q.Get("delivery_service"),
+		Currency:        q.Get("currency"),
+		Provider:        q.Get("provider"),
+		Bank:            q.Get("bank"),
+		Query:           strings.TrimSpace(q.Get("q")),
+		Limit:           defaultPageLimit,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return f, fmt.Errorf("invalid limit %q", v)
+		}
+		f.Limit = min(limit, maxPageLimit)
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := model.DecodeCursor(v)
+		if err != nil {
+			return f, err
+		}
+		// курсор поиска и обычного листинга не взаимозаменяемы
+		if (cursor.Rank != nil) != (f.Query != "") {
+			return f, model.ErrInvalidCursor
+		}
+		f.Cursor = cursor
+	}
+
+	var err error
+	if f.DateFrom, err = parseTimeParam(q, "date_from"); err != nil {
+		return f, err
+	}
+	if f.DateTo, err = parseEndTimeParam(q, "date_to"); err != nil {
+		return f, err
+	}
+	if f.AmountMin, err = parseIntParam(q, "amount_min"); err != nil {
+		return f, err
+	}
+	if f.AmountMax, err = parseIntParam(q, "amount_max"); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
+
+// parseOrderSearch разбирает ключи поиска: track_number, customer_id, phone, email, name, nm_id, brand, limit.
+// Нужен хотя бы один ключ.
+func parseOrderSearch(q url.Values) (model.OrderSearch, error) {
+	s := model.OrderSearch{
+		TrackNumber: q.Get("track_number"),
+		CustomerID:  q.Get("customer_id"),
+		Phone:       q.Get("phone"),
+		Email:       q.Get("email"),
+		Name:        q.Get("name"),
+		Brand:       q.Get("brand"),
+		Limit:       defaultPageLimit,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return s, fmt.Errorf("invalid limit %q", v)
+		}
+		s.Limit = min(limit, maxPageLimit)
+	}
+
+	var err error
+	if s.NmID, err = parseIntParam(q, "nm_id"); err != nil {
+		return s, err
+	}
+
+	if s.Empty() {
+		return s, fmt.Errorf("at least one of track_number, customer_id, phone, email, name, nm_id, brand is required")
+	}
+
+	return s, nil
+}
+
+// parseTimeParam разбирает нижнюю границу периода: RFC3339 или YYYY-MM-DD (начало дня UTC)
+func parseTimeParam(q url.Values, name string) (*time.Time, error) {
+	t, _, err := parseTimeValue(q, name)
+	return t, err
+}
+
+// parseEndTimeParam разбирает верхнюю исключающую границу периода. Дата YYYY-MM-DD
+// означает «по этот день включительно», поэтому возвращается начало следующего дня.
+func parseEndTimeParam(q url.Values, name string) (*time.Time, error) {
+	t, dateOnly, err := parseTimeValue(q, name)
+	if t != nil && dateOnly {
+		next := t.AddDate(0, 0, 1)
+		return &next, nil
+	}
+	return t, err
+}
+
+func parseTimeValue(q url.Values, name string) (t *time.Time, dateOnly bool, err error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, false, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, false, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return &t, true, nil
+	}
+	return nil, false, fmt.Errorf("invalid %s %q", name, v)
+}
+
+func parseIntParam(q url.Values, name string) (*int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return &n, nil
+}
@@ -0,0 +1,126 @@
+package serv
+
+import (
+	model "WB_Service/intrenal/models"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseTimeParams(t *testing.T) {
+	day := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	moment := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		wantFrom *time.Time
+		wantTo   *time.Time
+		wantErr  bool
+	}{
+		{name: "empty"},
+		{name: "date only", value: "2026-10-18", wantFrom: &day, wantTo: ptr(day.AddDate(0, 0, 1))},
+		{name: "rfc3339", value: "2026-10-18T12:30:00Z", wantFrom: &moment, wantTo: &moment},
+		{name: "rfc3339 with offset", value: "2026-10-18T15:30:00+03:00", wantFrom: &moment, wantTo: &moment},
+		{name: "garbage", value: "yesterday", wantErr: true},
+		{name: "date without zero padding", value: "2026-1-8", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := url.Values{"d": {tt.value}}
+
+			from, err := parseTimeParam(q, "d")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeParam error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !sameTime(from, tt.wantFrom) {
+				t.Errorf("parseTimeParam = %v, want %v", from, tt.wantFrom)
+			}
+
+			to, err := parseEndTimeParam(q, "d")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEndTimeParam error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !sameTime(to, tt.wantTo) {
+				t.Errorf("parseEndTimeParam = %v, want %v", to, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestParseOrderFilter(t *testing.T) {
+	listCursor := model.OrderCursor{DateCreated: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), OrderUID: "a"}.Encode()
+	rank := float32(0.5)
+	searchCursor := model.OrderCursor{DateCreated: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), OrderUID: "a", Rank: &rank}.Encode()
+
+	tests := []struct {
+		name      string
+		query     string
+		wantLimit int
+		wantErr   error
+		wantAny   bool
+	}{
+		{name: "defaults", query: "", wantLimit: defaultPageLimit},
+		{name: "limit capped", query: "limit=100000", wantLimit: maxPageLimit},
+		{name: "zero limit", query: "limit=0", wantAny: true},
+		{name: "list cursor", query: "cursor=" + listCursor, wantLimit: defaultPageLimit},
+		{name: "search cursor without q", query: "cursor=" + searchCursor, wantErr: model.ErrInvalidCursor},
+		{name: "list cursor with q", query: "q=phone&cursor=" + listCursor, wantErr: model.ErrInvalidCursor},
+		{name: "search cursor with q", query: "q=phone&cursor=" + searchCursor, wantLimit: defaultPageLimit},
+		{name: "broken cursor", query: "cursor=***", wantErr: model.ErrInvalidCursor},
+		{name: "bad amount", query: "amount_min=ten", wantAny: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := parseOrderFilter(q)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantAny:
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			default:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if f.Limit != tt.wantLimit {
+					t.Errorf("Limit = %d, want %d", f.Limit, tt.wantLimit)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOrderFilterDateToIncludesDay(t *testing.T) {
+	f, err := parseOrderFilter(url.Values{"date_from": {"2026-10-18"}, "date_to": {"2026-10-18"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inside := time.Date(2026, 10, 18, 23, 59, 59, 0, time.UTC)
+	if inside.Before(*f.DateFrom) || !inside.Before(*f.DateTo) {
+		t.Errorf("%v is outside [%v, %v)", inside, f.DateFrom, f.DateTo)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,69 @@
+package serv
+
+import (
+	"WB_Service/intrenal/db"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RevisionsHandler GET /order/{order_uid}/revisions
+func (h *Handler) RevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	orderUID := chi.URLParam(r, "order_uid")
+	revisions, err := h.service.OrderRevisions(ctx, orderUID)
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(revisions) == 0 {
+		http.Error(w, "order revisions not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"order_uid": orderUID,
+		"revisions": revisions,
+	})
+}
+
+// RevisionDiffHandler GET /order/{order_uid}/revisions/{from}/diff/{to}
+func (h *Handler) RevisionDiffHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	orderUID := chi.URLParam(r, "order_uid")
+	from, err := strconv.Atoi(chi.URLParam(r, "from"))
+	if err != nil || from <= 0 {
+		http.Error(w, "invalid revision "+chi.URLParam(r, "from"), http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.Atoi(chi.URLParam(r, "to"))
+	if err != nil || to <= 0 {
+		http.Error(w, "invalid revision "+chi.URLParam(r, "to"), http.StatusBadRequest)
+		return
+	}
+
+	changes, err := h.service.DiffOrderRevisions(ctx, orderUID, from, to)
+	if err != nil {
+		if errors.Is(err, db.ErrRevisionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"order_uid": orderUID,
+		"from":      from,
+		"to":        to,
+		"changes":   changes,
+	})
+}
@@ -0,0 +1,142 @@
+package serv
+
+import (
+	"WB_Service/intrenal/db"
+	model "WB_Service/intrenal/models"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// период статистики по умолчанию, если date_from не задан
+const defaultStatsPeriod = 30 * 24 * time.Hour
+
+type StatsService interface {
+	OrderStats(ctx context.Context, q model.StatsQuery) ([]model.StatsRow, error)
+}
+
+type StatsHandler struct {
+	stats StatsService
+}
+
+func NewStatsHandler(stats StatsService) *StatsHandler {
+	return &StatsHandler{
+		stats: stats,
+	}
+}
+
+// OrderStatsHandler GET /stats/orders?group_by=day,currency&date_from=&date_to=&format=json|csv
+func (h *StatsHandler) OrderStatsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	q, err := parseStatsQuery(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, fmt.Sprintf("invalid format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.stats.OrderStats(ctx, q)
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if rows == nil {
+		rows = []model.StatsRow{}
+	}
+
+	if format == "csv" {
+		writeStatsCSV(w, q, rows)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"group_by":  q.GroupBy,
+		"date_from": q.DateFrom,
+		"date_to":   q.DateTo,
+		"rows":      rows,
+	})
+}
+
+// parseStatsQuery: group_by — измерения через запятую (по умолчанию day,currency),
+// date_from/date_to — RFC3339 или YYYY-MM-DD (date_to-дата включает весь день), по умолчанию последние 30 дней.
+// date_to по умолчанию — начало следующей минуты: запросы без него в пределах минуты
+// дают один ключ кеша.
+func parseStatsQuery(v url.Values, now time.Time) (model.StatsQuery, error) {
+	q := model.StatsQuery{
+		GroupBy: []string{model.StatsByDay, model.StatsByCurrency},
+		DateTo:  now.Truncate(time.Minute).Add(time.Minute),
+	}
+
+	if g := v.Get("group_by"); g != "" {
+		q.GroupBy = q.GroupBy[:0]
+		seen := make(map[string]bool)
+		for _, dim := range strings.Split(g, ",") {
+			dim = strings.TrimSpace(dim)
+			if !db.ValidStatsDimension(dim) {
+				return q, fmt.Errorf("invalid group_by %q", dim)
+			}
+			if !seen[dim] {
+				seen[dim] = true
+				q.GroupBy = append(q.GroupBy, dim)
+			}
+		}
+	}
+
+	dateTo, err := parseEndTimeParam(v, "date_to")
+	if err != nil {
+		return q, err
+	}
+	if dateTo != nil {
+		q.DateTo = *dateTo
+	}
+
+	dateFrom, err := parseTimeParam(v, "date_from")
+	if err != nil {
+		return q, err
+	}
+	q.DateFrom = q.DateTo.Add(-defaultStatsPeriod)
+	if dateFrom != nil {
+		q.DateFrom = *dateFrom
+	}
+
+	if !q.DateFrom.Before(q.DateTo) {
+		return q, fmt.Errorf("date_from must be before date_to")
+	}
+
+	return q, nil
+}
+
+func writeStatsCSV(w http.ResponseWriter, q model.StatsQuery, rows []model.StatsRow) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="order-stats.csv"`)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(append(append([]string{}, q.GroupBy...), "orders", "amount", "delivery_cost", "custom_fee", "avg_amount"))
+	for _, row := range rows {
+		record := make([]string, 0, len(q.GroupBy)+5)
+		for _, dim := range q.GroupBy {
+			record = append(record, row.Group[dim])
+		}
+		record = append(record,
+			strconv.FormatInt(row.Orders, 10),
+			strconv.FormatInt(row.Amount, 10),
+			strconv.FormatInt(row.DeliveryCost, 10),
+			strconv.FormatInt(row.CustomFee, 10),
+			strconv.FormatFloat(row.AvgAmount, 'f', 2, 64),
+		)
+		_ = cw.Write(record)
+	}
+	cw.Flush()
+}
@@ -0,0 +1,67 @@
+package serv
+
+import (
+	"WB_Service/intrenal/db"
+	model "WB_Service/intrenal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type statusRequest struct {
+	Status string `json:"status"`
+}
+
+// ChangeStatusHandler PATCH /order/{order_uid}/status {"status": "paid"}
+// С If-Match переход выполняется, только если версия заказа не изменилась.
+func (h *Handler) ChangeStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	orderUID := chi.URLParam(r, "order_uid")
+	if orderUID == "" {
+		http.Error(w, "order_uid is required", http.StatusBadRequest)
+		return
+	}
+
+	expected, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req statusRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status, err := model.ParseOrderStatus(req.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	change, err := h.service.ChangeOrderStatus(ctx, orderUID, status, model.StatusSourceHTTP, expected)
+	switch {
+	case errors.Is(err, db.ErrOrderNotFound):
+		http.Error(w, "order not found", http.StatusNotFound)
+		return
+	case errors.Is(err, db.ErrVersionConflict):
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, model.ErrInvalidTransition):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(change.Version))
+	writeJSON(w, http.StatusOK, change)
+}
@@ -0,0 +1,220 @@
+package importer
+
+import (
+	model "WB_Service/intrenal/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Поля CSV совпадают с колонками выгрузки /orders/export: строка на товар,
+// поля заказа, delivery_* и payment_* повторяются и берутся из первой строки заказа.
+// status и version не импортируются, неизвестные колонки пропускаются.
+type orderSetter func(o *model.Order, v string) error
+
+type itemSetter func(it *model.Item, v string) error
+
+var orderSetters = map[string]orderSetter{
+	"order_uid":          func(o *model.Order, v string) error { o.OrderUUID = v; return nil },
+	"track_number":       func(o *model.Order, v string) error { o.TrackNumber = v; return nil },
+	"entry":              func(o *model.Order, v string) error { o.Entry = v; return nil },
+	"locale":             func(o *model.Order, v string) error { o.Locale = v; return nil },
+	"internal_signature": func(o *model.Order, v string) error { o.InternalSignature = v; return nil },
+	"customer_id":        func(o *model.Order, v string) error { o.CustomerID = v; return nil },
+	"delivery_service":   func(o *model.Order, v string) error { o.DeliveryService = v; return nil },
+	"shardkey":           func(o *model.Order, v string) error { o.Shardkey = v; return nil },
+	"sm_id":              func(o *model.Order, v string) error { return parseInt(&o.SmID, v) },
+	"date_created":       func(o *model.Order, v string) error { return parseTime(&o.DateCreated, v) },
+	"oof_shard":          func(o *model.Order, v string) error { o.OOFShard = v; return nil },
+
+	"delivery_name":    func(o *model.Order, v string) error { o.Delivery.Name = v; return nil },
+	"delivery_phone":   func(o *model.Order, v string) error { o.Delivery.Phone = v; return nil },
+	"delivery_zip":     func(o *model.Order, v string) error { o.Delivery.Zip = v; return nil },
+	"delivery_city":    func(o *model.Order, v string) error { o.Delivery.City = v; return nil },
+	"delivery_address": func(o *model.Order, v string) error { o.Delivery.Address = v; return nil },
+	"delivery_region":  func(o *model.Order, v string) error { o.Delivery.Region = v; return nil },
+	"delivery_email":   func(o *model.Order, v string) error { o.Delivery.Email = v; return nil },
+
+	"payment_transaction":   func(o *model.Order, v string) error { o.Payment.Transaction = v; return nil },
+	"payment_request_id":    func(o *model.Order, v string) error { o.Payment.RequestId = v; return nil },
+	"payment_currency":      func(o *model.Order, v string) error { o.Payment.Currency = v; return nil },
+	"payment_provider":      func(o *model.Order, v string) error { o.Payment.Provider = v; return nil },
+	"payment_amount":        func(o *model.Order, v string) error { return parseInt(&o.Payment.Amount, v) },
+	"payment_dt":            func(o *model.Order, v string) error { return parseInt64(&o.Payment.Payment, v) },
+	"payment_bank":          func(o *model.Order, v string) error { o.Payment.Bank = v; return nil },
+	"payment_delivery_cost": func(o *model.Order, v string) error { return parseInt(&o.Payment.DeliveryCost, v) },
+	"payment_goods_total":   func(o *model.Order, v string) error { return parseInt(&o.Payment.GoodsTotal, v) },
+	"payment_custom_fee":    func(o *model.Order, v string) error { return parseInt(&o.Payment.CustomFee, v) },
+}
+
+var itemSetters = map[string]itemSetter{
+	"item_chrt_id":      func(it *model.Item, v string) error { return parseInt(&it.ChrtID, v) },
+	"item_track_number": func(it *model.Item, v string) error { it.TrackNumber = v; return nil },
+	"item_price":        func(it *model.Item, v string) error { return parseInt(&it.Price, v) },
+	"item_rid":          func(it *model.Item, v string) error { it.Rid = v; return nil },
+	"item_name":         func(it *model.Item, v string) error { it.Name = v; return nil },
+	"item_sale":         func(it *model.Item, v string) error { return parseInt(&it.Sale, v) },
+	"item_size":         func(it *model.Item, v string) error { it.Size = v; return nil },
+	"item_total_price":  func(it *model.Item, v string) error { return parseInt(&it.TotalPrice, v) },
+	"item_nm_id":        func(it *model.Item, v string) error { return parseInt(&it.NmID, v) },
+	"item_brand":        func(it *model.Item, v string) error { it.Brand = v; return nil },
+	"item_status":       func(it *model.Item, v string) error { return parseInt(&it.Status, v) },
+}
+
+// csvReader собирает заказ из идущих подряд строк с одним order_uid
+type csvReader struct {
+	r       *csv.Reader
+	columns int
+	uidCol  int
+	orderAt map[int]orderSetter
+	itemAt  map[int]itemSetter
+
+	// pending — заказ, строки которого ещё могут продолжаться
+	pending *record
+	done    bool
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	c := &csvReader{
+		r:       cr,
+		columns: len(header),
+		uidCol:  -1,
+		orderAt: make(map[int]orderSetter),
+		itemAt:  make(map[int]itemSetter),
+	}
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if name == "order_uid" {
+			c.uidCol = i
+		}
+		if set, ok := orderSetters[name]; ok {
+			c.orderAt[i] = set
+		}
+		if set, ok := itemSetters[name]; ok {
+			c.itemAt[i] = set
+		}
+	}
+	if c.uidCol < 0 {
+		return nil, fmt.Errorf("csv header has no order_uid column")
+	}
+
+	return c, nil
+}
+
+func (c *csvReader) next() (record, error) {
+	for !c.done {
+		row, err := c.r.Read()
+		if errors.Is(err, io.EOF) {
+			c.done = true
+			break
+		}
+		line, _ := c.r.FieldPos(0)
+		if err != nil {
+			// после ошибки кавычек границы записей ненадёжны, дальше не читаем
+			return record{line: line}, fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		var rec *record
+		if len(row) != c.columns {
+			// строка с неверным числом полей — отдельная ошибочная запись
+			rec = &record{line: line, err: fmt.Errorf("line %d: expected %d fields, got %d", line, c.columns, len(row))}
+		} else if c.pending != nil && c.pending.order != nil && c.pending.order.OrderUUID == row[c.uidCol] {
+			c.addItem(c.pending, line, row)
+			continue
+		} else {
+			rec = c.newRecord(line, row)
+		}
+
+		ready := c.pending
+		c.pending = rec
+		if ready != nil {
+			return *ready, nil
+		}
+	}
+
+	if c.pending != nil {
+		rec := *c.pending
+		c.pending = nil
+		return rec, nil
+	}
+	return record{}, io.EOF
+}
+
+func (c *csvReader) newRecord(line int, row []string) *record {
+	rec := &record{line: line, order: &model.Order{}}
+	for i, set := range c.orderAt {
+		if err := set(rec.order, strings.TrimSpace(row[i])); err != nil && rec.err == nil {
+			rec.err = fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	c.addItem(rec, line, row)
+	return rec
+}
+
+// addItem добавляет товар из строки; строка без полей товара означает заказ без товаров
+func (c *csvReader) addItem(rec *record, line int, row []string) {
+	var item model.Item
+	empty := true
+	for i, set := range c.itemAt {
+		v := strings.TrimSpace(row[i])
+		if v == "" {
+			continue
+		}
+		empty = false
+		if err := set(&item, v); err != nil && rec.err == nil {
+			rec.err = fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if !empty {
+		rec.order.Items = append(rec.order.Items, item)
+	}
+}
+
+func parseInt(dst *int, v string) error {
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", v)
+	}
+	*dst = n
+	return nil
+}
+
+func parseInt64(dst *int64, v string) error {
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", v)
+	}
+	*dst = n
+	return nil
+}
+
+func parseTime(dst *time.Time, v string) error {
+	if v == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return fmt.Errorf("invalid time %q: expected RFC3339", v)
+	}
+	*dst = t
+	return nil
+}
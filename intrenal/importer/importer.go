@@ -0,0 +1,219 @@
+package importer
+
+import (
+	model "WB_Service/intrenal/models"
+	"WB_Service/intrenal/validation"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Форматы файла импорта
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+const defaultChunkSize = 500
+
+// ErrSave — пачку не удалось сохранить; файл при этом разобран корректно
+var ErrSave = errors.New("failed to save orders")
+
+// Store сохраняет пачку проверенных заказов (db.Postgres или service.Service).
+// stale — order_uid, не записанные из-за более нового заказа в БД.
+type Store interface {
+	ImportOrders(ctx context.Context, orders []*model.Order) (stale []string, err error)
+}
+
+type Options struct {
+	Format string
+	// DryRun — только разбор и проверка, в БД ничего не пишется
+	DryRun bool
+	// StartLine — номер строки файла (с 1), с которой продолжить импорт; более ранние записи пропускаются
+	StartLine int
+	// ChunkSize — сколько заказов сохраняется одной транзакцией
+	ChunkSize int
+}
+
+// LineError — ошибка записи, начинающейся на строке Line
+type LineError struct {
+	Line     int                     `json:"line"`
+	OrderUID string                  `json:"order_uid,omitempty"`
+	Error    string                  `json:"error"`
+	Fields   []validation.FieldError `json:"fields,omitempty"`
+}
+
+// StaleOrder — заказ из файла, который не записан: в БД он новее (date_created позже)
+type StaleOrder struct {
+	Line     int    `json:"line"`
+	OrderUID string `json:"order_uid"`
+}
+
+// Report — итог импорта. Skipped — записи до StartLine, Stale — валидные заказы,
+// пропущенные потому, что в БД уже более новая их версия.
+type Report struct {
+	DryRun      bool         `json:"dry_run"`
+	Read        int          `json:"read"`
+	Skipped     int          `json:"skipped"`
+	Valid       int          `json:"valid"`
+	Imported    int          `json:"imported"`
+	Stale       int          `json:"stale"`
+	Failed      int          `json:"failed"`
+	Errors      []LineError  `json:"errors"`
+	StaleOrders []StaleOrder `json:"stale_orders"`
+	// ResumeLine — если импорт прервался, строка, с которой его нужно повторить (StartLine)
+	ResumeLine int    `json:"resume_line,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// record — заказ из файла с номером первой строки; err — ошибка разбора
+type record struct {
+	line  int
+	order *model.Order
+	err   error
+}
+
+type recordReader interface {
+	// next возвращает io.EOF, когда записи закончились
+	next() (record, error)
+}
+
+// Importer читает заказы из NDJSON или CSV, проверяет их теми же правилами, что и consumer,
+// и сохраняет пачками. Невалидные записи попадают в отчёт и не мешают остальным.
+type Importer struct {
+	store     Store
+	validator *validation.Validator
+	log       *slog.Logger
+}
+
+func New(store Store, validator *validation.Validator, log *slog.Logger) *Importer {
+	return &Importer{
+		store:     store,
+		validator: validator,
+		log:       log,
+	}
+}
+
+// Import возвращает отчёт и при ошибке: по нему видно, что уже сохранено и откуда продолжить
+func (i *Importer) Import(ctx context.Context, r io.Reader, opts Options) (*Report, error) {
+	var rr recordReader
+	switch opts.Format {
+	case FormatNDJSON:
+		rr = newNDJSONReader(r)
+	case FormatCSV:
+		var err error
+		if rr, err = newCSVReader(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown import format %q", opts.Format)
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = defaultChunkSize
+	}
+
+	report := &Report{DryRun: opts.DryRun, Errors: []LineError{}, StaleOrders: []StaleOrder{}}
+	chunk := make([]*model.Order, 0, opts.ChunkSize)
+	// order_uid заказов пачки и строки, с которых они начинаются
+	inChunk := make(map[string]int, opts.ChunkSize)
+	chunkLine := 0
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if !opts.DryRun {
+			stale, err := i.store.ImportOrders(ctx, chunk)
+			if err != nil {
+				report.ResumeLine = chunkLine
+				return fmt.Errorf("%w from line %d: %w", ErrSave, chunkLine, err)
+			}
+			for _, uid := range stale {
+				report.StaleOrders = append(report.StaleOrders, StaleOrder{Line: inChunk[uid], OrderUID: uid})
+			}
+			report.Stale += len(stale)
+			report.Imported += len(chunk) - len(stale)
+			i.log.Info("import chunk saved",
+				slog.Int("orders", len(chunk)-len(stale)), slog.Int("stale", len(stale)), slog.Int("imported", report.Imported))
+		}
+		chunk = chunk[:0]
+		clear(inChunk)
+		return nil
+	}
+
+	fail := func(err error) (*Report, error) {
+		report.Error = err.Error()
+		return report, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			if len(chunk) > 0 {
+				report.ResumeLine = chunkLine
+			}
+			return fail(err)
+		}
+
+		rec, err := rr.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// файл дальше не читается — сохраняем то, что уже набрали
+			if flushErr := flush(); flushErr != nil {
+				return fail(flushErr)
+			}
+			report.ResumeLine = rec.line
+			return fail(err)
+		}
+
+		if rec.line < opts.StartLine {
+			report.Skipped++
+			continue
+		}
+		report.Read++
+
+		if rec.err != nil {
+			report.addError(rec, rec.err)
+			continue
+		}
+		if _, err := i.validator.Validate(rec.order); err != nil {
+			report.addError(rec, err)
+			continue
+		}
+		report.Valid++
+
+		// один order_uid дважды в пачке upsert не переживёт: более поздняя запись уходит в следующую пачку
+		if _, dup := inChunk[rec.order.OrderUUID]; dup || len(chunk) >= opts.ChunkSize {
+			if err := flush(); err != nil {
+				return fail(err)
+			}
+		}
+		if len(chunk) == 0 {
+			chunkLine = rec.line
+		}
+		chunk = append(chunk, rec.order)
+		inChunk[rec.order.OrderUUID] = rec.line
+	}
+
+	if err := flush(); err != nil {
+		return fail(err)
+	}
+	return report, nil
+}
+
+func (r *Report) addError(rec record, err error) {
+	r.Failed++
+
+	e := LineError{Line: rec.line, Error: err.Error()}
+	if rec.order != nil {
+		e.OrderUID = rec.order.OrderUUID
+	}
+	if vErr, ok := validation.AsError(err); ok {
+		e.Error = "validation failed"
+		e.Fields = vErr.Fields
+	}
+	r.Errors = append(r.Errors, e)
+}
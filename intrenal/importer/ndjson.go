@@ -0,0 +1,48 @@
+package importer
+
+import (
+	model "WB_Service/intrenal/models"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ndjsonReader — один заказ в JSON на строку, пустые строки пропускаются
+type ndjsonReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	return &ndjsonReader{r: bufio.NewReaderSize(r, 64<<10)}
+}
+
+func (n *ndjsonReader) next() (record, error) {
+	for {
+		data, err := n.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			if errors.Is(err, io.EOF) {
+				return record{}, io.EOF
+			}
+			return record{line: n.line + 1}, fmt.Errorf("failed to read line %d: %w", n.line+1, err)
+		}
+		n.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		rec := record{line: n.line}
+		var order model.Order
+		if err := json.Unmarshal(data, &order); err != nil {
+			rec.err = fmt.Errorf("bad json: %w", err)
+			return rec, nil
+		}
+		rec.order = &order
+		return rec, nil
+	}
+}
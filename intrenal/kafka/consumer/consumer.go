@@ -2,79 +2,374 @@ package consumer
 
 import (
 	"WB_Service/intrenal/config"
+	"WB_Service/intrenal/db"
 	serv "WB_Service/intrenal/http/handler"
+	"WB_Service/intrenal/kafka/dlq"
+	"WB_Service/intrenal/lib/backoff"
+	"WB_Service/intrenal/lib/sl"
+	"WB_Service/intrenal/metrics"
 	model "WB_Service/intrenal/models"
+	"WB_Service/intrenal/validation"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/IBM/sarama"
-	"github.com/go-playground/validator/v10"
-	"log"
+	"log/slog"
+	"strconv"
+	"sync"
 	"time"
 )
 
-var validate = validator.New()
+const (
+	saveTimeout      = 5 * time.Second
+	batchSaveTimeout = 15 * time.Second
+)
+
+// причины попадания сообщения в DLQ для метрик
+const (
+	failureInvalid = "invalid"
+	failureSave    = "save"
+	failureStatus  = "status"
+)
+
+// errInvalidMessage — сообщение, которое бессмысленно обрабатывать повторно
+var errInvalidMessage = errors.New("invalid message")
 
 type Consumer struct {
 	OrderService serv.OrderService
+	Validator    *validation.Validator
+	// StatusTopic — топик событий смены статуса, остальные топики содержат заказы
+	StatusTopic string
+	// StatusRetryTopic — куда откладываются события статуса для ещё не пришедших заказов;
+	// читается той же группой. Пусто — такие события сразу уходят в DLQ.
+	StatusRetryTopic string
+	Group            sarama.ConsumerGroup
+	DLQ              *dlq.Publisher
+	// Producer публикует отложенные события статуса
+	Producer sarama.SyncProducer
+	// MaxAttempts ограничивает число попыток при временных ошибках БД, 0 — без ограничения
+	MaxAttempts int
+	Backoff     backoff.Config
+	// Workers — число параллельных обработчиков на одну партицию
+	Workers int
+	// BatchSize и BatchTimeout ограничивают пачку, сохраняемую одной транзакцией
+	BatchSize    int
+	BatchTimeout time.Duration
+	Log          *slog.Logger
+
+	pausedMu sync.Mutex
+	paused   map[topicPartition]int
+
+	// procCtx отменяется, только если остановка не уложилась в дедлайн
+	procCtx context.Context
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
 }
 
 func (c *Consumer) Setup(_ sarama.ConsumerGroupSession) error {
-	log.Println("[Setup] Starting session KAFKA CONSUMER...")
+	c.Log.Info("Starting session KAFKA CONSUMER...")
 	return nil
 }
 
 func (c *Consumer) Cleanup(_ sarama.ConsumerGroupSession) error {
-	log.Println("[Cleanup] Cleaning up session...")
+	c.Log.Info("Cleaning up session...")
 	return nil
 }
 
 func (c *Consumer) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		var order model.Order
-		if err := json.Unmarshal(msg.Value, &order); err != nil {
-			log.Println("Kafka: bad message:", err)
-			continue
-		}
+	// оффсет помечаем только после сохранения или передачи сообщения в DLQ
+	// и только когда обработаны все более ранние сообщения партиции.
+	// Пул живёт в контексте обработки, а не сессии: при остановке или ребалансе
+	// уже выданные воркерам сообщения дорабатываются и их оффсеты коммитятся.
+	handle := c.handleBatch
+	if claim.Topic() == c.StatusTopic || claim.Topic() == c.StatusRetryTopic {
+		handle = c.handleStatusBatch
+	}
+	pool := newPartitionPool(c.procCtx, c.Workers, c.BatchSize, c.BatchTimeout, newOffsetTracker(sess), handle)
 
-		// проверка на nil
-		if order.Delivery == (model.Delivery{}) || order.Payment == (model.Payment{}) || len(order.Items) == 0 {
-			log.Println("Kafka: order has empty required fields")
-			continue
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				err := pool.wait()
+				sess.Commit()
+				return err
+			}
+			metrics.ConsumerLag.WithLabelValues(claim.Topic(), strconv.Itoa(int(claim.Partition()))).
+				Set(float64(max(claim.HighWaterMarkOffset()-msg.Offset-1, 0)))
+			if !pool.dispatch(msg) {
+				return c.stopClaim(claim, pool)
+			}
+		case <-sess.Context().Done():
+			err := c.stopClaim(claim, pool)
+			sess.Commit()
+			return err
+		case <-pool.done():
+			return c.stopClaim(claim, pool)
 		}
+	}
+}
+
+func (c *Consumer) stopClaim(claim sarama.ConsumerGroupClaim, pool *partitionPool) error {
+	err := pool.wait()
+	if err != nil {
+		c.Log.Error("Kafka: partition stopped, uncommitted messages will be redelivered",
+			slog.String("topic", claim.Topic()),
+			slog.Int("partition", int(claim.Partition())),
+			sl.Err(err),
+		)
+	}
+	return err
+}
+
+// handleBatch сохраняет пачку сообщений одной транзакцией.
+// Невалидные сообщения уходят в DLQ, а заказы, которые не записались в пачке,
+// сохраняются по одному с повторами. Если пачка не прошла целиком или заказ в ней
+// был пропущен из-за другой версии, у него ещё не было своей попытки — она делается заново.
+func (c *Consumer) handleBatch(ctx context.Context, msgs []*sarama.ConsumerMessage) error {
+	topic, partition := msgs[0].Topic, strconv.Itoa(int(msgs[0].Partition))
+	defer func(start time.Time) {
+		metrics.ConsumerBatchDuration.WithLabelValues(topic, partition).Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	valid := make([]*sarama.ConsumerMessage, 0, len(msgs))
+	orders := make([]*model.Order, 0, len(msgs))
 
-		// валидируем
-		if err := validate.Struct(&order); err != nil {
-			log.Println("Kafka: struct validation failed:", err)
+	for _, msg := range msgs {
+		order, err := c.decodeOrder(msg)
+		if err != nil {
+			c.Log.Warn("Kafka: bad message", slog.Int64("offset", msg.Offset), sl.Err(err))
+			if err := c.deadLetter(msg, err, 1, failureInvalid); err != nil {
+				return err
+			}
 			continue
 		}
+		valid = append(valid, msg)
+		orders = append(orders, order)
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if err := c.OrderService.SaveOrder(ctx, &order); err != nil {
-			log.Println("Kafka: save order failed:", err)
+	if len(orders) == 0 {
+		return nil
+	}
+
+	saveCtx, cancel := context.WithTimeout(ctx, batchSaveTimeout)
+	errs, err := c.OrderService.SaveOrders(saveCtx, orders)
+	cancel()
+	if err != nil {
+		// транзакция пачки целиком не прошла — сохраняем по одному
+		c.Log.Warn("Kafka: batch save failed, saving orders one by one", slog.Int("orders", len(orders)), sl.Err(err))
+		for i := range orders {
+			if err := c.saveWithRetry(ctx, valid[i], orders[i], nil); err != nil {
+				return err
+			}
 		}
-		cancel()
+		return nil
+	}
 
-		sess.MarkMessage(msg, "")
+	for i, err := range errs {
+		if err == nil {
+			metrics.ConsumerProcessed.WithLabelValues(topic, partition).Inc()
+			continue
+		}
+		if errors.Is(err, db.ErrSkippedInBatch) {
+			err = nil
+		}
+		if err := c.saveWithRetry(ctx, valid[i], orders[i], err); err != nil {
+			return err
+		}
 	}
+
 	return nil
 }
 
-func subscribe(ctx context.Context, topic string, consumerGroup sarama.ConsumerGroup, service serv.OrderService) error {
-	consumer := &Consumer{OrderService: service}
-	go func() {
-		for {
-			if err := consumerGroup.Consume(ctx, []string{topic}, consumer); err != nil {
-				log.Printf("Error from consumer: %v", err)
-			}
-			if ctx.Err() != nil {
-				return
-			}
+// saveWithRetry повторяет сохранение заказа после неудачной первой попытки firstErr;
+// при firstErr == nil первая попытка делается здесь же
+func (c *Consumer) saveWithRetry(ctx context.Context, msg *sarama.ConsumerMessage, order *model.Order, firstErr error) error {
+	return c.retry(ctx, msg, order.OrderUUID, firstErr, failureSave, func(ctx context.Context) error {
+		return c.OrderService.SaveOrder(ctx, order)
+	})
+}
+
+// retry повторяет операцию над сообщением после неудачной первой попытки firstErr
+// (nil — попыток ещё не было, op вызывается сразу).
+// Временные ошибки повторяются с backoff, остальные сразу уходят в DLQ с причиной kind.
+// Запись, отклонённая как устаревшая политикой out_of_order, пропускается.
+func (c *Consumer) retry(ctx context.Context, msg *sarama.ConsumerMessage, orderUID string, firstErr error, kind string, op func(context.Context) error) error {
+	paused := false
+	defer func() {
+		if paused {
+			c.resume(msg.Topic, msg.Partition)
 		}
 	}()
+
+	err := firstErr
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 || firstErr == nil {
+			saveCtx, cancel := context.WithTimeout(ctx, saveTimeout)
+			err = op(saveCtx)
+			cancel()
+			if err == nil {
+				metrics.ConsumerProcessed.WithLabelValues(msg.Topic, strconv.Itoa(int(msg.Partition))).Inc()
+				return nil
+			}
+		}
+
+		// сессия закрывается (ребаланс/остановка) — сообщение перечитаем позже
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if db.IsStaleWrite(err) {
+			c.skipStale(msg, orderUID, err)
+			return nil
+		}
+
+		// постоянные ошибки повторять бессмысленно
+		if !db.IsTransient(err) || (c.MaxAttempts > 0 && attempt >= c.MaxAttempts) {
+			return c.deadLetter(msg, err, attempt, kind)
+		}
+
+		delay := c.Backoff.Delay(attempt)
+		c.Log.Warn("Kafka: processing failed, retrying",
+			slog.String("order_uid", orderUID),
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", delay),
+			sl.Err(err),
+		)
+
+		// пока ждём, не выбираем новые сообщения из партиции
+		if !paused {
+			c.pause(msg.Topic, msg.Partition)
+			paused = true
+		}
+		if err := backoff.Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// pause приостанавливает выборку из партиции; несколько воркеров могут ждать одновременно,
+// поэтому партиция возобновляется, когда последний из них закончил
+func (c *Consumer) pause(topic string, partition int32) {
+	c.pausedMu.Lock()
+	defer c.pausedMu.Unlock()
+
+	if c.paused == nil {
+		c.paused = make(map[topicPartition]int)
+	}
+	key := topicPartition{topic, partition}
+	if c.paused[key] == 0 {
+		c.Group.Pause(map[string][]int32{topic: {partition}})
+	}
+	c.paused[key]++
+}
+
+func (c *Consumer) resume(topic string, partition int32) {
+	c.pausedMu.Lock()
+	defer c.pausedMu.Unlock()
+
+	key := topicPartition{topic, partition}
+	c.paused[key]--
+	if c.paused[key] <= 0 {
+		delete(c.paused, key)
+		c.Group.Resume(map[string][]int32{topic: {partition}})
+	}
+}
+
+// skipStale подтверждает сообщение, которое пришло не по порядку и не должно перезаписать заказ
+func (c *Consumer) skipStale(msg *sarama.ConsumerMessage, orderUID string, reason error) {
+	metrics.ConsumerSkipped.WithLabelValues(msg.Topic, strconv.Itoa(int(msg.Partition))).Inc()
+
+	c.Log.Info("Kafka: out-of-order message skipped",
+		slog.String("order_uid", orderUID),
+		slog.Int("partition", int(msg.Partition)),
+		slog.Int64("offset", msg.Offset),
+		slog.String("reason", reason.Error()),
+	)
+}
+
+func (c *Consumer) deadLetter(msg *sarama.ConsumerMessage, reason error, attempts int, kind string) error {
+	if err := c.DLQ.Publish(msg, reason, attempts); err != nil {
+		return err
+	}
+	metrics.ConsumerFailed.WithLabelValues(msg.Topic, strconv.Itoa(int(msg.Partition)), kind).Inc()
+
+	c.Log.Info("Kafka: message sent to DLQ",
+		slog.Int("partition", int(msg.Partition)),
+		slog.Int64("offset", msg.Offset),
+		slog.String("reason", reason.Error()),
+	)
 	return nil
 }
 
-func StartConsumer(ctx context.Context, cfg *config.Config, service serv.OrderService) error {
+func (c *Consumer) decodeOrder(msg *sarama.ConsumerMessage) (*model.Order, error) {
+	var order model.Order
+	if err := json.Unmarshal(msg.Value, &order); err != nil {
+		return nil, fmt.Errorf("%w: bad json: %v", errInvalidMessage, err)
+	}
+
+	// те же правила, что и в POST /publish-order
+	warnings, err := c.Validator.Validate(&order)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidMessage, err)
+	}
+	for _, w := range warnings {
+		c.Log.Warn("Kafka: order consistency warning",
+			slog.String("order_uid", order.OrderUUID),
+			slog.Int64("offset", msg.Offset),
+			slog.String("rule", w.Rule),
+			slog.String("field", w.Field),
+			slog.String("message", w.Message),
+		)
+	}
+
+	return &order, nil
+}
+
+// Group — запущенная consumer group с управляемой остановкой
+type Group struct {
+	group      sarama.ConsumerGroup
+	consumer   *Consumer
+	cancel     context.CancelFunc
+	procCancel context.CancelFunc
+	done       chan struct{}
+}
+
+func (g *Group) run(ctx context.Context, topics []string) {
+	defer close(g.done)
+	for {
+		if err := g.group.Consume(ctx, topics, g.consumer); err != nil {
+			g.consumer.Log.Error("Error from consumer", sl.Err(err))
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// Close выходит из группы: новые сообщения не читаются, выданные воркерам дорабатываются,
+// их оффсеты коммитятся. Если ctx истёк раньше, обработка прерывается и незакоммиченное
+// будет перечитано после ребаланса.
+func (g *Group) Close(ctx context.Context) error {
+	g.cancel()
+
+	select {
+	case <-g.done:
+	case <-ctx.Done():
+		g.consumer.Log.Warn("Kafka: drain deadline exceeded, aborting in-flight messages")
+		g.procCancel()
+		<-g.done
+	}
+	g.procCancel()
+
+	return g.group.Close()
+}
+
+func StartConsumer(ctx context.Context, cfg *config.Config, service serv.OrderService, validator *validation.Validator, dlqPublisher *dlq.Publisher, producer sarama.SyncProducer, log *slog.Logger) (*Group, error) {
 
 	saramaCfg := sarama.NewConfig()
 
@@ -84,9 +379,47 @@ func StartConsumer(ctx context.Context, cfg *config.Config, service serv.OrderSe
 	// создаем ConsumerGroup
 	consumerGroup, err := sarama.NewConsumerGroup(cfg.Kafka.Brokers, cfg.Kafka.GroupID, saramaCfg)
 	if err != nil {
-		log.Printf("Ошибка при создании consumer group: %s\n", err)
-		return err
+		log.Error("Ошибка при создании consumer group", sl.Err(err))
+		return nil, err
+	}
+
+	// жизненным циклом группы управляет только Close
+	ctx = context.WithoutCancel(ctx)
+	procCtx, procCancel := context.WithCancel(ctx)
+	runCtx, cancel := context.WithCancel(ctx)
+
+	consumer := &Consumer{
+		OrderService:     service,
+		Validator:        validator,
+		StatusTopic:      cfg.Kafka.StatusTopic,
+		StatusRetryTopic: cfg.Kafka.StatusRetryTopic,
+		Group:            consumerGroup,
+		DLQ:              dlqPublisher,
+		Producer:         producer,
+		MaxAttempts:      cfg.Kafka.MaxAttempts,
+		Backoff:          cfg.Kafka.Retry,
+		Workers:          cfg.Kafka.Workers,
+		BatchSize:        cfg.Kafka.BatchSize,
+		BatchTimeout:     cfg.Kafka.BatchTimeout,
+		Log:              log,
+		procCtx:          procCtx,
+	}
+
+	g := &Group{
+		group:      consumerGroup,
+		consumer:   consumer,
+		cancel:     cancel,
+		procCancel: procCancel,
+		done:       make(chan struct{}),
+	}
+	topics := []string{cfg.Kafka.Topic}
+	if cfg.Kafka.StatusTopic != "" {
+		topics = append(topics, cfg.Kafka.StatusTopic)
+		if cfg.Kafka.StatusRetryTopic != "" {
+			topics = append(topics, cfg.Kafka.StatusRetryTopic)
+		}
 	}
+	go g.run(runCtx, topics)
 
-	return subscribe(ctx, cfg.Kafka.Topic, consumerGroup, service)
+	return g, nil
 }
@@ -0,0 +1,179 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// offsetTracker помечает оффсеты партиции только непрерывным префиксом:
+// оффсет коммитится, когда обработаны все сообщения до него включительно
+type offsetTracker struct {
+	mu      sync.Mutex
+	sess    sarama.ConsumerGroupSession
+	pending []*sarama.ConsumerMessage
+	done    map[int64]bool
+}
+
+func newOffsetTracker(sess sarama.ConsumerGroupSession) *offsetTracker {
+	return &offsetTracker{
+		sess: sess,
+		done: make(map[int64]bool),
+	}
+}
+
+// add регистрирует сообщение в порядке чтения из партиции
+func (t *offsetTracker) add(msg *sarama.ConsumerMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = append(t.pending, msg)
+}
+
+func (t *offsetTracker) complete(msg *sarama.ConsumerMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done[msg.Offset] = true
+
+	var last *sarama.ConsumerMessage
+	for len(t.pending) > 0 && t.done[t.pending[0].Offset] {
+		last = t.pending[0]
+		delete(t.done, last.Offset)
+		t.pending[0] = nil
+		t.pending = t.pending[1:]
+	}
+
+	if last != nil {
+		t.sess.MarkMessage(last, "")
+	}
+}
+
+// partitionPool обрабатывает сообщения одной партиции в нескольких воркерах.
+// Сообщения с одинаковым ключом (order_uid) всегда попадают в один воркер и идут по порядку.
+// Воркер копит пачку до batchSize сообщений или batchTimeout с первого сообщения и обрабатывает её целиком.
+type partitionPool struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	queues  []chan *sarama.ConsumerMessage
+	tracker *offsetTracker
+	wg      sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+type batchHandler func(context.Context, []*sarama.ConsumerMessage) error
+
+func newPartitionPool(ctx context.Context, workers, batchSize int, batchTimeout time.Duration, tracker *offsetTracker, handle batchHandler) *partitionPool {
+	workers = max(workers, 1)
+	ctx, cancel := context.WithCancel(ctx)
+
+	p := &partitionPool{
+		ctx:     ctx,
+		cancel:  cancel,
+		queues:  make([]chan *sarama.ConsumerMessage, workers),
+		tracker: tracker,
+	}
+
+	for i := range p.queues {
+		queue := make(chan *sarama.ConsumerMessage, max(batchSize, 1))
+		p.queues[i] = queue
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.work(queue, max(batchSize, 1), batchTimeout, handle)
+		}()
+	}
+
+	return p
+}
+
+func (p *partitionPool) work(queue <-chan *sarama.ConsumerMessage, batchSize int, batchTimeout time.Duration, handle batchHandler) {
+	batch := make([]*sarama.ConsumerMessage, 0, batchSize)
+	timer := time.NewTimer(batchTimeout)
+	timer.Stop()
+	defer timer.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if p.ctx.Err() == nil {
+			if err := handle(p.ctx, batch); err != nil {
+				p.fail(err)
+			} else {
+				// оффсеты пачки помечаем только после коммита
+				for _, msg := range batch {
+					p.tracker.complete(msg)
+				}
+			}
+		}
+		batch = make([]*sarama.ConsumerMessage, 0, batchSize)
+	}
+
+	for {
+		select {
+		case msg, ok := <-queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, msg)
+			if len(batch) == 1 {
+				timer.Reset(batchTimeout)
+			}
+			if len(batch) >= batchSize {
+				timer.Stop()
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// dispatch отдаёт сообщение воркеру; false — пул остановлен из-за ошибки
+func (p *partitionPool) dispatch(msg *sarama.ConsumerMessage) bool {
+	h := fnv.New32a()
+	_, _ = h.Write(msg.Key)
+	queue := p.queues[h.Sum32()%uint32(len(p.queues))]
+
+	p.tracker.add(msg)
+	select {
+	case queue <- msg:
+		return true
+	case <-p.ctx.Done():
+		return false
+	}
+}
+
+func (p *partitionPool) done() <-chan struct{} {
+	return p.ctx.Done()
+}
+
+// wait дожидается обработки уже выданных сообщений и возвращает первую ошибку
+func (p *partitionPool) wait() error {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.wg.Wait()
+	p.cancel()
+
+	if errors.Is(p.err, context.Canceled) {
+		return nil
+	}
+	return p.err
+}
+
+func (p *partitionPool) fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
@@ -0,0 +1,54 @@
+package consumer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// markSession записывает оффсеты, которые tracker пометил к коммиту
+type markSession struct {
+	sarama.ConsumerGroupSession
+	marked []int64
+}
+
+func (s *markSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+func TestOffsetTracker(t *testing.T) {
+	tests := []struct {
+		name     string
+		offsets  []int64
+		complete []int64
+		want     []int64
+	}{
+		{name: "in order", offsets: []int64{1, 2, 3}, complete: []int64{1, 2, 3}, want: []int64{1, 2, 3}},
+		{name: "gap waits for prefix", offsets: []int64{1, 2, 3}, complete: []int64{3, 2}, want: nil},
+		{name: "gap closed marks last", offsets: []int64{1, 2, 3}, complete: []int64{3, 2, 1}, want: []int64{3}},
+		{name: "partial prefix", offsets: []int64{10, 11, 12, 13}, complete: []int64{11, 10, 13}, want: []int64{11}},
+		{name: "non contiguous offsets", offsets: []int64{5, 9, 20}, complete: []int64{9, 5, 20}, want: []int64{9, 20}},
+		{name: "nothing completed", offsets: []int64{1, 2}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess := &markSession{}
+			tracker := newOffsetTracker(sess)
+
+			msgs := make(map[int64]*sarama.ConsumerMessage)
+			for _, off := range tt.offsets {
+				msgs[off] = &sarama.ConsumerMessage{Offset: off}
+				tracker.add(msgs[off])
+			}
+			for _, off := range tt.complete {
+				tracker.complete(msgs[off])
+			}
+
+			if !slices.Equal(sess.marked, tt.want) {
+				t.Errorf("marked = %v, want %v", sess.marked, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package consumer
+
+import (
+	"WB_Service/intrenal/db"
+	"WB_Service/intrenal/lib/backoff"
+	"WB_Service/intrenal/lib/sl"
+	"WB_Service/intrenal/metrics"
+	model "WB_Service/intrenal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// statusEvent — сообщение топика статусов
+type statusEvent struct {
+	OrderUID string `json:"order_uid"`
+	Status   string `json:"status"`
+	// Source попадает в историю статусов, по умолчанию kafka
+	Source string `json:"source"`
+}
+
+// Заголовки сообщения в топике отложенных статусов
+const (
+	headerStatusAttempt   = "x-status-attempt"
+	headerStatusNotBefore = "x-status-not-before"
+)
+
+// handleStatusBatch применяет смены статуса по одной в порядке партиции.
+// Статусы идут отдельным топиком и могут обогнать сам заказ. Событие для неизвестного заказа
+// не ждёт в партиции, а откладывается в StatusRetryTopic и повторяется оттуда с backoff
+// до MaxAttempts — основная партиция статусов не блокируется. Запрещённый переход сразу уходит в DLQ.
+// Отложенное событие может применяться уже после более поздних событий того же заказа:
+// такой переход будет запрещён и попадёт в DLQ.
+func (c *Consumer) handleStatusBatch(ctx context.Context, msgs []*sarama.ConsumerMessage) error {
+	for _, msg := range msgs {
+		event, status, err := decodeStatusEvent(msg)
+		if err != nil {
+			c.Log.Warn("Kafka: bad status message", slog.Int64("offset", msg.Offset), sl.Err(err))
+			if err := c.deadLetter(msg, err, 1, failureInvalid); err != nil {
+				return err
+			}
+			continue
+		}
+
+		attempt, notBefore := statusRetryState(msg)
+		if err := c.waitUntil(ctx, msg, notBefore); err != nil {
+			return err
+		}
+
+		apply := func(ctx context.Context) error {
+			_, err := c.OrderService.ChangeOrderStatus(ctx, event.OrderUID, status, event.Source, 0)
+			return err
+		}
+
+		saveCtx, cancel := context.WithTimeout(ctx, saveTimeout)
+		err = apply(saveCtx)
+		cancel()
+
+		switch {
+		case err == nil:
+			metrics.ConsumerProcessed.WithLabelValues(msg.Topic, strconv.Itoa(int(msg.Partition))).Inc()
+		case errors.Is(err, db.ErrOrderNotFound):
+			if err := c.parkStatus(msg, event.OrderUID, attempt+1, err); err != nil {
+				return err
+			}
+		case errors.Is(err, model.ErrInvalidTransition):
+			if err := c.deadLetter(msg, err, attempt+1, failureStatus); err != nil {
+				return err
+			}
+		default:
+			// временные ошибки БД повторяются на месте, как при сохранении заказов
+			err := c.retry(ctx, msg, event.OrderUID, err, failureStatus, func(ctx context.Context) error {
+				err := apply(ctx)
+				if errors.Is(err, db.ErrOrderNotFound) {
+					return c.parkStatus(msg, event.OrderUID, attempt+1, err)
+				}
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parkStatus откладывает событие статуса в StatusRetryTopic на Backoff.Delay(attempt).
+// После MaxAttempts попыток или без StatusRetryTopic событие уходит в DLQ.
+func (c *Consumer) parkStatus(msg *sarama.ConsumerMessage, orderUID string, attempt int, reason error) error {
+	if c.StatusRetryTopic == "" || (c.MaxAttempts > 0 && attempt >= c.MaxAttempts) {
+		return c.deadLetter(msg, reason, attempt, failureStatus)
+	}
+
+	notBefore := time.Now().Add(c.Backoff.Delay(attempt))
+	headers := []sarama.RecordHeader{
+		{Key: []byte(headerStatusAttempt), Value: []byte(strconv.Itoa(attempt))},
+		{Key: []byte(headerStatusNotBefore), Value: []byte(strconv.FormatInt(notBefore.UnixMilli(), 10))},
+	}
+	for _, h := range msg.Headers {
+		if h != nil && !strings.HasPrefix(string(h.Key), "x-status-") {
+			headers = append(headers, *h)
+		}
+	}
+
+	_, _, err := c.Producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   c.StatusRetryTopic,
+		Key:     sarama.ByteEncoder(msg.Key),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to park status event in %s: %w", c.StatusRetryTopic, err)
+	}
+	metrics.ConsumerParked.WithLabelValues(msg.Topic, strconv.Itoa(int(msg.Partition))).Inc()
+
+	c.Log.Info("Kafka: status event for unknown order parked",
+		slog.String("order_uid", orderUID),
+		slog.Int("attempt", attempt),
+		slog.Time("not_before", notBefore),
+		slog.String("reason", reason.Error()),
+	)
+	return nil
+}
+
+// waitUntil ждёт времени повтора отложенного события, не выбирая пока новые сообщения из партиции.
+// События в партиции StatusRetryTopic идут примерно по возрастанию времени повтора,
+// поэтому ожидание первого из них почти не задерживает остальные.
+func (c *Consumer) waitUntil(ctx context.Context, msg *sarama.ConsumerMessage, notBefore time.Time) error {
+	delay := time.Until(notBefore)
+	if delay <= 0 {
+		return nil
+	}
+
+	c.pause(msg.Topic, msg.Partition)
+	defer c.resume(msg.Topic, msg.Partition)
+	return backoff.Sleep(ctx, delay)
+}
+
+// statusRetryState читает номер попытки и время повтора отложенного события;
+// у события из основного топика статусов их нет
+func statusRetryState(msg *sarama.ConsumerMessage) (attempt int, notBefore time.Time) {
+	for _, h := range msg.Headers {
+		if h == nil {
+			continue
+		}
+		switch string(h.Key) {
+		case headerStatusAttempt:
+			attempt, _ = strconv.Atoi(string(h.Value))
+		case headerStatusNotBefore:
+			if ms, err := strconv.ParseInt(string(h.Value), 10, 64); err == nil {
+				notBefore = time.UnixMilli(ms)
+			}
+		}
+	}
+	return attempt, notBefore
+}
+
+func decodeStatusEvent(msg *sarama.ConsumerMessage) (*statusEvent, model.OrderStatus, error) {
+	var event statusEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return nil, "", fmt.Errorf("%w: bad json: %v", errInvalidMessage, err)
+	}
+	if event.OrderUID == "" {
+		return nil, "", fmt.Errorf("%w: order_uid is required", errInvalidMessage)
+	}
+
+	status, err := model.ParseOrderStatus(event.Status)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", errInvalidMessage, err)
+	}
+	if event.Source == "" {
+		event.Source = model.StatusSourceKafka
+	}
+
+	return &event, status, nil
+}
@@ -0,0 +1,49 @@
+package consumer
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func TestStatusRetryState(t *testing.T) {
+	notBefore := time.UnixMilli(time.Now().UnixMilli())
+
+	tests := []struct {
+		name          string
+		headers       []*sarama.RecordHeader
+		wantAttempt   int
+		wantNotBefore time.Time
+	}{
+		{name: "original topic"},
+		{
+			name: "parked event",
+			headers: []*sarama.RecordHeader{
+				{Key: []byte("x-trace-id"), Value: []byte("abc")},
+				{Key: []byte(headerStatusAttempt), Value: []byte("3")},
+				{Key: []byte(headerStatusNotBefore), Value: []byte(strconv.FormatInt(notBefore.UnixMilli(), 10))},
+			},
+			wantAttempt:   3,
+			wantNotBefore: notBefore,
+		},
+		{
+			name: "broken not-before",
+			headers: []*sarama.RecordHeader{
+				{Key: []byte(headerStatusAttempt), Value: []byte("2")},
+				{Key: []byte(headerStatusNotBefore), Value: []byte("soon")},
+			},
+			wantAttempt: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempt, got := statusRetryState(&sarama.ConsumerMessage{Headers: tt.headers})
+			if attempt != tt.wantAttempt || !got.Equal(tt.wantNotBefore) {
+				t.Errorf("statusRetryState() = %d, %v; want %d, %v", attempt, got, tt.wantAttempt, tt.wantNotBefore)
+			}
+		})
+	}
+}
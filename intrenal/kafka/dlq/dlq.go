@@ -0,0 +1,261 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/IBM/sarama"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки, которыми помечается сообщение в DLQ. Тело сообщения остаётся исходным.
+const (
+	HeaderTopic     = "x-dlq-original-topic"
+	HeaderPartition = "x-dlq-original-partition"
+	HeaderOffset    = "x-dlq-original-offset"
+	HeaderError     = "x-dlq-error"
+	HeaderAttempts  = "x-dlq-attempts"
+	HeaderFailedAt  = "x-dlq-failed-at"
+	HeaderRedriven  = "x-dlq-redriven-from"
+)
+
+var ErrMessageNotFound = errors.New("dlq message not found")
+
+// MaxListLimit — сколько сообщений на партицию List читает максимум, чтобы не тянуть в память весь топик
+const MaxListLimit = 1000
+
+// Message — сообщение из DLQ в виде, удобном для админки
+type Message struct {
+	Partition         int32     `json:"partition"`
+	Offset            int64     `json:"offset"`
+	Key               string    `json:"key"`
+	Payload           string    `json:"payload"`
+	OriginalTopic     string    `json:"original_topic"`
+	OriginalPartition int32     `json:"original_partition"`
+	OriginalOffset    int64     `json:"original_offset"`
+	Error             string    `json:"error"`
+	Attempts          int       `json:"attempts"`
+	FailedAt          time.Time `json:"failed_at"`
+
+	// headers — исходные заголовки сообщения без служебных x-dlq-*, возвращаются при redrive
+	headers []sarama.RecordHeader
+}
+
+// Publisher отправляет сообщения, которые не удалось обработать, в DLQ-топик
+type Publisher struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func NewPublisher(producer sarama.SyncProducer, topic string) *Publisher {
+	return &Publisher{
+		producer: producer,
+		topic:    topic,
+	}
+}
+
+func (p *Publisher) Publish(msg *sarama.ConsumerMessage, reason error, attempts int) error {
+	headers := []sarama.RecordHeader{
+		header(HeaderTopic, msg.Topic),
+		header(HeaderPartition, strconv.FormatInt(int64(msg.Partition), 10)),
+		header(HeaderOffset, strconv.FormatInt(msg.Offset, 10)),
+		header(HeaderError, reason.Error()),
+		header(HeaderAttempts, strconv.Itoa(attempts)),
+		header(HeaderFailedAt, time.Now().UTC().Format(time.RFC3339Nano)),
+	}
+	// сохраняем исходные заголовки, кроме служебных от прошлого попадания в DLQ
+	for _, h := range msg.Headers {
+		if h != nil && !strings.HasPrefix(string(h.Key), "x-dlq-") {
+			headers = append(headers, *h)
+		}
+	}
+
+	_, _, err := p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   p.topic,
+		Key:     sarama.ByteEncoder(msg.Key),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to dlq %s: %w", p.topic, err)
+	}
+	return nil
+}
+
+// Admin позволяет просматривать DLQ и возвращать сообщения в основной топик
+type Admin struct {
+	client    sarama.Client
+	producer  sarama.SyncProducer
+	topic     string
+	mainTopic string
+}
+
+func NewAdmin(client sarama.Client, producer sarama.SyncProducer, topic, mainTopic string) *Admin {
+	return &Admin{
+		client:    client,
+		producer:  producer,
+		topic:     topic,
+		mainTopic: mainTopic,
+	}
+}
+
+// List возвращает до limit (не больше MaxListLimit) последних сообщений из каждой партиции DLQ
+func (a *Admin) List(ctx context.Context, limit int) ([]Message, error) {
+	limit = min(limit, MaxListLimit)
+
+	partitions, err := a.client.Partitions(a.topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dlq partitions: %w", err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(a.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dlq consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	var messages []Message
+	for _, partition := range partitions {
+		oldest, newest, err := a.offsets(partition)
+		if err != nil {
+			return nil, err
+		}
+		if newest <= oldest {
+			continue
+		}
+
+		start := max(oldest, newest-int64(limit))
+		msgs, err := a.read(ctx, consumer, partition, start, newest)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msgs...)
+	}
+
+	return messages, nil
+}
+
+// Redrive перечитывает сообщение из DLQ и отправляет его обратно в исходный топик
+// с исходными заголовками и пометкой, откуда оно вернулось
+func (a *Admin) Redrive(ctx context.Context, partition int32, offset int64) (int32, int64, error) {
+	// за последним сообщением партиции чтение ждало бы новых записей до таймаута запроса
+	oldest, newest, err := a.offsets(partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	if offset < oldest || offset >= newest {
+		return 0, 0, ErrMessageNotFound
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(a.client)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create dlq consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	msgs, err := a.read(ctx, consumer, partition, offset, offset+1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(msgs) == 0 || msgs[0].Offset != offset {
+		return 0, 0, ErrMessageNotFound
+	}
+	m := msgs[0]
+
+	topic := m.OriginalTopic
+	if topic == "" {
+		topic = a.mainTopic
+	}
+
+	headers := append(m.headers, header(HeaderRedriven, fmt.Sprintf("%d/%d", partition, offset)))
+
+	return a.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.StringEncoder(m.Key),
+		Value:   sarama.StringEncoder(m.Payload),
+		Headers: headers,
+	})
+}
+
+// offsets возвращает границы партиции DLQ: первое доступное сообщение и следующее за последним
+func (a *Admin) offsets(partition int32) (oldest, newest int64, err error) {
+	oldest, err = a.client.GetOffset(a.topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get oldest offset: %w", err)
+	}
+	newest, err = a.client.GetOffset(a.topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get newest offset: %w", err)
+	}
+	return oldest, newest, nil
+}
+
+// read читает сообщения партиции в диапазоне [from, to)
+func (a *Admin) read(ctx context.Context, consumer sarama.Consumer, partition int32, from, to int64) ([]Message, error) {
+	pc, err := consumer.ConsumePartition(a.topic, partition, from)
+	if err != nil {
+		if errors.Is(err, sarama.ErrOffsetOutOfRange) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to consume dlq partition %d: %w", partition, err)
+	}
+	defer pc.Close()
+
+	var messages []Message
+	for {
+		select {
+		case msg := <-pc.Messages():
+			messages = append(messages, fromConsumerMessage(msg))
+			if msg.Offset+1 >= to {
+				return messages, nil
+			}
+		case err := <-pc.Errors():
+			return nil, fmt.Errorf("failed to read dlq partition %d: %w", partition, err)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func fromConsumerMessage(msg *sarama.ConsumerMessage) Message {
+	m := Message{
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       string(msg.Key),
+		Payload:   string(msg.Value),
+	}
+
+	for _, h := range msg.Headers {
+		if h == nil {
+			continue
+		}
+		v := string(h.Value)
+		switch string(h.Key) {
+		case HeaderTopic:
+			m.OriginalTopic = v
+		case HeaderPartition:
+			p, _ := strconv.ParseInt(v, 10, 32)
+			m.OriginalPartition = int32(p)
+		case HeaderOffset:
+			m.OriginalOffset, _ = strconv.ParseInt(v, 10, 64)
+		case HeaderError:
+			m.Error = v
+		case HeaderAttempts:
+			m.Attempts, _ = strconv.Atoi(v)
+		case HeaderFailedAt:
+			m.FailedAt, _ = time.Parse(time.RFC3339Nano, v)
+		default:
+			if !strings.HasPrefix(string(h.Key), "x-dlq-") {
+				m.headers = append(m.headers, *h)
+			}
+		}
+	}
+
+	return m
+}
+
+func header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
@@ -0,0 +1,134 @@
+package outbox
+
+import (
+	"WB_Service/intrenal/lib/backoff"
+	"WB_Service/intrenal/lib/sl"
+	model "WB_Service/intrenal/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Заголовки события заказа. Тело сообщения — JSON из outbox (заказ или смена статуса).
+const (
+	HeaderEventType = "x-event-type"
+	HeaderEventID   = "x-event-id"
+)
+
+type Config struct {
+	Enabled      bool           `yaml:"enabled" env-default:"true"`
+	Topic        string         `yaml:"topic" env-default:"order-events"`
+	PollInterval time.Duration  `yaml:"poll_interval" env-default:"1s"`
+	BatchSize    int            `yaml:"batch_size" env-default:"100"`
+	Lease        time.Duration  `yaml:"lease" env-default:"1m"` // больше времени отправки пачки в Kafka
+	Retry        backoff.Config `yaml:"retry"`
+}
+
+// Store — хранилище outbox (db.Postgres)
+type Store interface {
+	ProcessOutbox(ctx context.Context, limit int, lease time.Duration, publish func(model.OrderEvent) error, retryDelay func(attempts int) time.Duration) (sent, failed int, err error)
+}
+
+// Relay переносит события из outbox в Kafka.
+// Событие помечается отправленным только после подтверждения от брокера,
+// поэтому при недоступности Kafka оно остаётся в таблице и уходит позже.
+type Relay struct {
+	store    Store
+	producer sarama.SyncProducer
+	cfg      Config
+	log      *slog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewRelay(store Store, producer sarama.SyncProducer, cfg Config, log *slog.Logger) *Relay {
+	return &Relay{
+		store:    store,
+		producer: producer,
+		cfg:      cfg,
+		log:      log,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start запускает relay в отдельной горутине. Relay не зависит от отмены ctx
+// и останавливается только через Close, чтобы успеть отправить события,
+// записанные consumer-ом во время дренажа.
+func (r *Relay) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	go func() {
+		defer close(r.done)
+		r.run(ctx)
+	}()
+}
+
+// Close останавливает relay и ждёт окончания текущей пачки
+func (r *Relay) Close(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("outbox relay did not stop: %w", ctx.Err())
+	}
+}
+
+func (r *Relay) run(ctx context.Context) {
+	r.log.Info("outbox relay started", "topic", r.cfg.Topic)
+
+	ticker := time.NewTicker(max(r.cfg.PollInterval, 10*time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		// пока пачки полные, разгребаем outbox без ожидания
+		for {
+			sent, failed, err := r.store.ProcessOutbox(ctx, max(r.cfg.BatchSize, 1), max(r.cfg.Lease, time.Second), r.publish, r.cfg.Retry.Delay)
+			if err != nil {
+				if ctx.Err() == nil {
+					r.log.Error("Error processing outbox", sl.Err(err))
+				}
+				break
+			}
+			if failed > 0 {
+				r.log.Warn("outbox events not published, will retry", "sent", sent, "failed", failed)
+			}
+			if sent+failed < r.cfg.BatchSize || failed > 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			r.log.Info("outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) publish(e model.OrderEvent) error {
+	_, _, err := r.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: r.cfg.Topic,
+		Key:   sarama.StringEncoder(e.OrderUID),
+		Value: sarama.ByteEncoder(e.Payload),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(HeaderEventType), Value: []byte(e.Type)},
+			{Key: []byte(HeaderEventID), Value: []byte(strconv.FormatInt(e.ID, 10))},
+		},
+		Timestamp: e.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish %s event %d: %w", e.Type, e.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package producer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+type Config struct {
+	Topic string `yaml:"topic" env-default:"orders"`
+	// RequiredAcks: none | leader | all
+	RequiredAcks string        `yaml:"required_acks" env-default:"all"`
+	Retries      int           `yaml:"retries" env-default:"5"`
+	RetryBackoff time.Duration `yaml:"retry_backoff" env-default:"100ms"`
+	// Compression: none | gzip | snappy | lz4 | zstd
+	Compression     string `yaml:"compression" env-default:"none"`
+	Idempotent      bool   `yaml:"idempotent" env-default:"true"`
+	MaxMessageBytes int    `yaml:"max_message_bytes" env-default:"1000000"`
+	ClientID        string `yaml:"client_id" env-default:"wb-service"`
+}
+
+// SaramaConfig собирает и проверяет конфигурацию sarama для синхронного producer
+func (c Config) SaramaConfig() (*sarama.Config, error) {
+	if c.Topic == "" {
+		return nil, fmt.Errorf("producer topic is empty")
+	}
+
+	cfg := sarama.NewConfig()
+	cfg.ClientID = c.ClientID
+	cfg.Producer.Return.Successes = true
+	cfg.Producer.Return.Errors = true
+	cfg.Producer.Retry.Max = c.Retries
+	cfg.Producer.Retry.Backoff = c.RetryBackoff
+	cfg.Producer.MaxMessageBytes = c.MaxMessageBytes
+	cfg.Producer.Idempotent = c.Idempotent
+
+	switch strings.ToLower(c.RequiredAcks) {
+	case "none", "0":
+		cfg.Producer.RequiredAcks = sarama.NoResponse
+	case "leader", "1":
+		cfg.Producer.RequiredAcks = sarama.WaitForLocal
+	case "all", "-1":
+		cfg.Producer.RequiredAcks = sarama.WaitForAll // ждать подтверждения от всех реплик
+	default:
+		return nil, fmt.Errorf("unknown producer required_acks %q", c.RequiredAcks)
+	}
+
+	switch strings.ToLower(c.Compression) {
+	case "none", "":
+		cfg.Producer.Compression = sarama.CompressionNone
+	case "gzip":
+		cfg.Producer.Compression = sarama.CompressionGZIP
+	case "snappy":
+		cfg.Producer.Compression = sarama.CompressionSnappy
+	case "lz4":
+		cfg.Producer.Compression = sarama.CompressionLZ4
+	case "zstd":
+		cfg.Producer.Compression = sarama.CompressionZSTD
+	default:
+		return nil, fmt.Errorf("unknown producer compression %q", c.Compression)
+	}
+
+	// идемпотентный producer требует acks=all, повторов и одного запроса в полёте
+	if c.Idempotent {
+		if cfg.Producer.RequiredAcks != sarama.WaitForAll {
+			return nil, fmt.Errorf("idempotent producer requires required_acks=all")
+		}
+		if c.Retries <= 0 {
+			return nil, fmt.Errorf("idempotent producer requires retries > 0")
+		}
+		cfg.Net.MaxOpenRequests = 1
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid producer config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func New(brokers []string, c Config) (sarama.SyncProducer, error) {
+	cfg, err := c.SaramaConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	producer, err := sarama.NewSyncProducer(brokers, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create producer: %w", err)
+	}
+
+	return producer, nil
+}
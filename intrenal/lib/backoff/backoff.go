@@ -0,0 +1,49 @@
+package backoff
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Config — экспоненциальная задержка с джиттером между повторными попытками
+type Config struct {
+	InitialInterval time.Duration `yaml:"initial_interval" env-default:"200ms"`
+	MaxInterval     time.Duration `yaml:"max_interval" env-default:"30s"`
+	Multiplier      float64       `yaml:"multiplier" env-default:"2"`
+	// Jitter — доля задержки (0..1), на которую она случайно уменьшается
+	Jitter float64 `yaml:"jitter" env-default:"0.2"`
+}
+
+// Delay возвращает задержку перед попыткой attempt (начиная с 1)
+func (c Config) Delay(attempt int) time.Duration {
+	multiplier := c.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	d := float64(c.InitialInterval) * math.Pow(multiplier, float64(max(attempt-1, 0)))
+	if c.MaxInterval > 0 && d > float64(c.MaxInterval) {
+		d = float64(c.MaxInterval)
+	}
+
+	if c.Jitter > 0 {
+		d -= d * min(c.Jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(d)
+}
+
+// Sleep ждёт d или отмены контекста
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,67 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		attempt int
+		want    time.Duration
+	}{
+		{name: "first attempt", cfg: Config{InitialInterval: 100 * time.Millisecond, Multiplier: 2}, attempt: 1, want: 100 * time.Millisecond},
+		{name: "exponential", cfg: Config{InitialInterval: 100 * time.Millisecond, Multiplier: 2}, attempt: 4, want: 800 * time.Millisecond},
+		{name: "capped", cfg: Config{InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second, Multiplier: 2}, attempt: 20, want: time.Second},
+		{name: "multiplier below one is constant", cfg: Config{InitialInterval: time.Second, Multiplier: 0.5}, attempt: 5, want: time.Second},
+		{name: "attempt zero as first", cfg: Config{InitialInterval: time.Second, Multiplier: 3}, attempt: 0, want: time.Second},
+		{name: "no cap", cfg: Config{InitialInterval: time.Millisecond, Multiplier: 10}, attempt: 4, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	tests := []struct {
+		name   string
+		jitter float64
+		min    time.Duration
+	}{
+		{name: "partial", jitter: 0.2, min: 800 * time.Millisecond},
+		{name: "clamped to one", jitter: 5, min: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{InitialInterval: time.Second, Multiplier: 2, Jitter: tt.jitter}
+			for range 1000 {
+				d := cfg.Delay(1)
+				if d < tt.min || d > time.Second {
+					t.Fatalf("Delay(1) = %v, want within [%v, 1s]", d, tt.min)
+				}
+			}
+		})
+	}
+}
+
+func TestSleepCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := Sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("Sleep = %v, want context.Canceled", err)
+	}
+	if err := Sleep(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("Sleep = %v, want nil", err)
+	}
+}
@@ -0,0 +1,59 @@
+package lifecycle
+
+import (
+	"WB_Service/intrenal/lib/sl"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type step struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Manager останавливает компоненты строго в порядке добавления.
+// Все шаги делят один общий дедлайн; если он истёк, оставшиеся шаги
+// всё равно вызываются с отменённым контекстом, чтобы освободить ресурсы.
+type Manager struct {
+	steps []step
+	log   *slog.Logger
+}
+
+func New(log *slog.Logger) *Manager {
+	return &Manager{
+		log: log,
+	}
+}
+
+func (m *Manager) Add(name string, stop func(ctx context.Context) error) {
+	m.steps = append(m.steps, step{name: name, stop: stop})
+}
+
+// AddFunc добавляет шаг без контекста и ошибки, например Close() пула
+func (m *Manager) AddFunc(name string, stop func()) {
+	m.Add(name, func(context.Context) error {
+		stop()
+		return nil
+	})
+}
+
+func (m *Manager) Shutdown(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var errs []error
+	for _, s := range m.steps {
+		start := time.Now()
+		if err := s.stop(ctx); err != nil {
+			m.log.Error("shutdown step failed", slog.String("step", s.name), sl.Err(err))
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			continue
+		}
+		m.log.Info("shutdown step done", slog.String("step", s.name), slog.Duration("elapsed", time.Since(start)))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"WB_Service/intrenal/cache"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type CacheStatser interface {
+	Stats() cache.Stats
+}
+
+// cacheCollector снимает статистику кеша в момент scrape
+type cacheCollector struct {
+	source CacheStatser
+
+	hits, misses, evictions, expirations, entries, bytes *prometheus.Desc
+}
+
+func NewCacheCollector(source CacheStatser) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", name), help, nil, nil)
+	}
+
+	return &cacheCollector{
+		source:      source,
+		hits:        desc("hits_total", "Cache hits."),
+		misses:      desc("misses_total", "Cache misses, including expired entries."),
+		evictions:   desc("evictions_total", "Entries evicted by the eviction policy."),
+		expirations: desc("expirations_total", "Entries removed after TTL."),
+		entries:     desc("entries", "Current number of entries."),
+		bytes:       desc("bytes", "Approximate memory used by entries."),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.expirations
+	ch <- c.entries
+	ch <- c.bytes
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.source.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(s.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(s.Expirations))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(s.Entries))
+	ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.GaugeValue, float64(s.Bytes))
+}
+
+type PoolStatser interface {
+	Stat() *pgxpool.Stat
+}
+
+// poolCollector снимает статистику pgxpool в момент scrape
+type poolCollector struct {
+	source PoolStatser
+
+	acquireCount, acquireDuration, canceledAcquire, emptyAcquire      *prometheus.Desc
+	acquiredConns, idleConns, constructingConns, totalConns, maxConns *prometheus.Desc
+}
+
+func NewPoolCollector(source PoolStatser) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		source:            source,
+		acquireCount:      desc("acquire_total", "Successful connection acquires."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		canceledAcquire:   desc("canceled_acquire_total", "Acquires canceled by context."),
+		emptyAcquire:      desc("empty_acquire_total", "Acquires that had to wait for a connection."),
+		acquiredConns:     desc("acquired_conns", "Connections currently in use."),
+		idleConns:         desc("idle_conns", "Idle connections."),
+		constructingConns: desc("constructing_conns", "Connections being established."),
+		totalConns:        desc("total_conns", "Total connections in the pool."),
+		maxConns:          desc("max_conns", "Maximum pool size."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.acquireCount, c.acquireDuration, c.canceledAcquire, c.emptyAcquire,
+		c.acquiredConns, c.idleConns, c.constructingConns, c.totalConns, c.maxConns} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.source.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(s.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// HTTPMiddleware считает запросы и задержку по шаблону маршрута chi,
+// чтобы значения order_uid и т.п. не раздували кардинальность
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		HTTPDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "wb_service"
+
+// Registry — реестр метрик сервиса, отдаётся на /metrics
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+// HTTP
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by chi route pattern, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by chi route pattern and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Kafka consumer
+var (
+	ConsumerProcessed = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka_consumer",
+		Name:      "messages_processed_total",
+		Help:      "Messages saved successfully.",
+	}, []string{"topic", "partition"})
+
+	ConsumerFailed = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka_consumer",
+		Name:      "messages_failed_total",
+		Help:      "Messages sent to DLQ by failure reason.",
+	}, []string{"topic", "partition", "reason"})
+
+	ConsumerSkipped = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka_consumer",
+		Name:      "messages_skipped_total",
+		Help:      "Order events skipped as out of order by the configured policy.",
+	}, []string{"topic", "partition"})
+
+	ConsumerParked = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka_consumer",
+		Name:      "messages_parked_total",
+		Help:      "Status events for not yet received orders moved to the status retry topic.",
+	}, []string{"topic", "partition"})
+
+	ConsumerLag = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "kafka_consumer",
+		Name:      "lag",
+		Help:      "Difference between partition high water mark and the last received offset.",
+	}, []string{"topic", "partition"})
+
+	ConsumerBatchDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "kafka_consumer",
+		Name:      "batch_processing_seconds",
+		Help:      "Time to decode, validate and persist a batch of messages.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic", "partition"})
+)
+
+// Kafka producer
+var (
+	ProducerSendDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "kafka_producer",
+		Name:      "send_duration_seconds",
+		Help:      "Synchronous send latency.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	ProducerErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka_producer",
+		Name:      "errors_total",
+		Help:      "Failed sends.",
+	}, []string{"topic"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler отдаёт метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// instrumentedProducer замеряет задержку и ошибки отправки
+type instrumentedProducer struct {
+	sarama.SyncProducer
+}
+
+func InstrumentProducer(producer sarama.SyncProducer) sarama.SyncProducer {
+	return &instrumentedProducer{SyncProducer: producer}
+}
+
+func (p *instrumentedProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	start := time.Now()
+	partition, offset, err := p.SyncProducer.SendMessage(msg)
+
+	ProducerSendDuration.WithLabelValues(msg.Topic).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ProducerErrors.WithLabelValues(msg.Topic).Inc()
+	}
+	return partition, offset, err
+}
+
+func (p *instrumentedProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	start := time.Now()
+	err := p.SyncProducer.SendMessages(msgs)
+	elapsed := time.Since(start).Seconds()
+
+	for _, msg := range msgs {
+		ProducerSendDuration.WithLabelValues(msg.Topic).Observe(elapsed)
+	}
+	if errs, ok := err.(sarama.ProducerErrors); ok {
+		for _, e := range errs {
+			ProducerErrors.WithLabelValues(e.Msg.Topic).Inc()
+		}
+	}
+	return err
+}
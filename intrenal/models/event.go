@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+const (
+	EventOrderCreated = "OrderCreated"
+	EventOrderUpdated = "OrderUpdated"
+	// EventOrderStatusChanged несёт model.StatusChange, а не заказ
+	EventOrderStatusChanged = "OrderStatusChanged"
+)
+
+// OrderEvent — запись outbox, которую нужно доставить в Kafka
+type OrderEvent struct {
+	ID        int64     `json:"id"`
+	OrderUID  string    `json:"order_uid"`
+	Type      string    `json:"event_type"`
+	Payload   []byte    `json:"payload"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+}
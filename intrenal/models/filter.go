@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// OrderFilter описывает выборку заказов для листинга.
+// Пустые поля не участвуют в фильтрации.
+type OrderFilter struct {
+	CustomerID      string
+	DeliveryService string
+	Currency        string
+	Provider        string
+	Bank            string
+	DateFrom        *time.Time
+	DateTo          *time.Time
+	AmountMin       *int
+	AmountMax       *int
+	// Query — полнотекстовый запрос по товарам и адресу; с ним заказы идут по релевантности
+	Query string
+
+	Limit  int
+	Cursor *OrderCursor
+}
+
+// OrderCursor — позиция keyset-пагинации по (date_created, order_uid).
+// Rank задан только для выдачи полнотекстового поиска, упорядоченной по (rank DESC, date_created, order_uid).
+type OrderCursor struct {
+	DateCreated time.Time `json:"d"`
+	OrderUID    string    `json:"u"`
+	Rank        *float32  `json:"r,omitempty"`
+}
+
+type OrderPage struct {
+	Orders     []*Order `json:"orders"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func (c OrderCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c OrderCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.OrderUID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	rank := float32(0.25)
+
+	tests := []struct {
+		name   string
+		cursor OrderCursor
+	}{
+		{name: "listing", cursor: OrderCursor{DateCreated: time.Date(2026, 10, 18, 10, 0, 0, 123, time.UTC), OrderUID: "b563feb7b2b84b6test"}},
+		{name: "search", cursor: OrderCursor{DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC), OrderUID: "x", Rank: &rank}},
+		{name: "epoch date", cursor: OrderCursor{DateCreated: time.Unix(0, 0).UTC(), OrderUID: "old"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if !got.DateCreated.Equal(tt.cursor.DateCreated) || got.OrderUID != tt.cursor.OrderUID {
+				t.Errorf("got %+v, want %+v", got, tt.cursor)
+			}
+			if (got.Rank == nil) != (tt.cursor.Rank == nil) || (got.Rank != nil && *got.Rank != *tt.cursor.Rank) {
+				t.Errorf("Rank = %v, want %v", got.Rank, tt.cursor.Rank)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	enc := base64.RawURLEncoding.EncodeToString
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "not base64", input: "%%%"},
+		{name: "not json", input: enc([]byte("hello"))},
+		{name: "no order uid", input: enc([]byte(`{"d":"2026-10-18T00:00:00Z"}`))},
+		{name: "bad date", input: enc([]byte(`{"d":"today","u":"a"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.input); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// IdempotencyRecord — сохранённый запрос с Idempotency-Key.
+// Response пустой, пока первый запрос с этим ключом ещё выполняется.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	Response    []byte
+	ExpiresAt   time.Time
+}
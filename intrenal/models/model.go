@@ -17,6 +17,10 @@ type Order struct {
 	SmID              int       `json:"sm_id" db:"sm_id"`
 	DateCreated       time.Time `json:"date_created" db:"date_created" validate:"required"`
 	OOFShard          string    `json:"oof_shard" db:"oof_shard"`
+	// Status меняется только переходами статуса, при сохранении заказа не записывается
+	Status OrderStatus `json:"status,omitempty" db:"status"`
+	// Version — текущая версия заказа. Во входящем заказе — ожидаемая версия (как If-Match), 0 — любая.
+	Version int64 `json:"version,omitempty" db:"version"`
 }
 
 type Delivery struct {
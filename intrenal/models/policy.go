@@ -0,0 +1,25 @@
+package model
+
+import "fmt"
+
+// OutOfOrderPolicy определяет, что делать с событием заказа без ожидаемой версии, пришедшим не по порядку.
+// Событие с версией (If-Match) применяется, только если версия совпадает, при любой политике.
+type OutOfOrderPolicy string
+
+const (
+	// OutOfOrderSkip — событие без версии применяется, только если его date_created строго новее
+	// сохранённого; более старые и повторные события пропускаются
+	OutOfOrderSkip OutOfOrderPolicy = "skip"
+	// OutOfOrderLastWriteWins — из событий без версии побеждает заказ с более поздним date_created,
+	// при равном date_created — пришедший последним; более старые события пропускаются
+	OutOfOrderLastWriteWins OutOfOrderPolicy = "last_write_wins"
+)
+
+func (p OutOfOrderPolicy) Validate() error {
+	switch p {
+	case OutOfOrderSkip, OutOfOrderLastWriteWins:
+		return nil
+	default:
+		return fmt.Errorf("unknown out-of-order policy %q", p)
+	}
+}
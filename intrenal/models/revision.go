@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// OrderRevision — снимок содержимого заказа после сохранения, изменившего данные
+type OrderRevision struct {
+	OrderUID  string    `json:"order_uid"`
+	Revision  int       `json:"revision"`
+	CreatedAt time.Time `json:"created_at"`
+	Order     *Order    `json:"order"`
+}
+
+// FieldChange — изменение одного поля. Path строится по json-именам: payment.amount, items[0].price.
+// Для добавленного или удалённого товара Old или New равен null.
+type FieldChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// DiffOrders возвращает поля, которые отличаются в b по сравнению с a.
+// Товары сравниваются по позиции в списке.
+func DiffOrders(a, b *Order) []FieldChange {
+	changes := make([]FieldChange, 0)
+	diffValue("", reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), &changes)
+	return changes
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func diffValue(path string, a, b reflect.Value, changes *[]FieldChange) {
+	switch {
+	case a.Type() == timeType:
+		if !a.Interface().(time.Time).Equal(b.Interface().(time.Time)) {
+			*changes = append(*changes, FieldChange{Path: path, Old: a.Interface(), New: b.Interface()})
+		}
+
+	case a.Kind() == reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			name := jsonName(a.Type().Field(i))
+			if name == "" {
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			diffValue(name, a.Field(i), b.Field(i), changes)
+		}
+
+	case a.Kind() == reflect.Slice:
+		for i := 0; i < max(a.Len(), b.Len()); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= a.Len():
+				*changes = append(*changes, FieldChange{Path: p, New: b.Index(i).Interface()})
+			case i >= b.Len():
+				*changes = append(*changes, FieldChange{Path: p, Old: a.Index(i).Interface()})
+			default:
+				diffValue(p, a.Index(i), b.Index(i), changes)
+			}
+		}
+
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*changes = append(*changes, FieldChange{Path: path, Old: a.Interface(), New: b.Interface()})
+		}
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffOrders(t *testing.T) {
+	created := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	base := func() *Order {
+		return &Order{
+			OrderUUID:   "a",
+			TrackNumber: "TRACK",
+			Payment:     Payment{Amount: 100, Currency: "RUB"},
+			Items: []Item{
+				{ChrtID: 1, Price: 50, Name: "first"},
+				{ChrtID: 2, Price: 50, Name: "second"},
+			},
+			DateCreated: created,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(o *Order)
+		want   []FieldChange
+	}{
+		{
+			name:   "no changes",
+			modify: func(o *Order) {},
+			want:   []FieldChange{},
+		},
+		{
+			name:   "top-level field",
+			modify: func(o *Order) { o.TrackNumber = "OTHER" },
+			want:   []FieldChange{{Path: "track_number", Old: "TRACK", New: "OTHER"}},
+		},
+		{
+			name:   "nested field",
+			modify: func(o *Order) { o.Payment.Amount = 120 },
+			want:   []FieldChange{{Path: "payment.amount", Old: 100, New: 120}},
+		},
+		{
+			name:   "item field by position",
+			modify: func(o *Order) { o.Items[1].Price = 70 },
+			want:   []FieldChange{{Path: "items[1].price", Old: 50, New: 70}},
+		},
+		{
+			name:   "item added",
+			modify: func(o *Order) { o.Items = append(o.Items, Item{ChrtID: 3}) },
+			want:   []FieldChange{{Path: "items[2]", New: Item{ChrtID: 3}}},
+		},
+		{
+			name:   "item removed",
+			modify: func(o *Order) { o.Items = o.Items[:1] },
+			want:   []FieldChange{{Path: "items[1]", Old: Item{ChrtID: 2, Price: 50, Name: "second"}}},
+		},
+		{
+			name:   "same instant in another location",
+			modify: func(o *Order) { o.DateCreated = created.In(time.FixedZone("MSK", 3*60*60)) },
+			want:   []FieldChange{},
+		},
+		{
+			name:   "date changed",
+			modify: func(o *Order) { o.DateCreated = created.Add(time.Hour) },
+			want:   []FieldChange{{Path: "date_created", Old: created, New: created.Add(time.Hour)}},
+		},
+		{
+			name: "several fields in struct order",
+			modify: func(o *Order) {
+				o.Status = StatusPaid
+				o.OrderUUID = "b"
+			},
+			want: []FieldChange{
+				{Path: "order_uid", Old: "a", New: "b"},
+				{Path: "status", Old: OrderStatus(""), New: StatusPaid},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := base(), base()
+			tt.modify(b)
+
+			if got := DiffOrders(a, b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffOrders() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+// OrderSearch — ключи поиска заказов. Заданные ключи объединяются через AND.
+// TrackNumber, CustomerID, Phone и NmID сравниваются точно,
+// Name, Email и Brand — по вхождению подстроки без учёта регистра.
+type OrderSearch struct {
+	TrackNumber string
+	CustomerID  string
+	Phone       string
+	Email       string
+	Name        string
+	NmID        *int
+	Brand       string
+
+	Limit int
+}
+
+func (s OrderSearch) Empty() bool {
+	return s.TrackNumber == "" && s.CustomerID == "" && s.Phone == "" && s.Email == "" &&
+		s.Name == "" && s.NmID == nil && s.Brand == ""
+}
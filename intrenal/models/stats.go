@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Измерения группировки статистики заказов
+const (
+	StatsByDay             = "day"
+	StatsByCurrency        = "currency"
+	StatsByProvider        = "provider"
+	StatsByBank            = "bank"
+	StatsByDeliveryService = "delivery_service"
+)
+
+// StatsQuery — параметры статистики: заказы с DateFrom <= date_created < DateTo,
+// сгруппированные по измерениям GroupBy в заданном порядке
+type StatsQuery struct {
+	GroupBy  []string
+	DateFrom time.Time
+	DateTo   time.Time
+}
+
+// Key — ключ запроса для кеша
+func (q StatsQuery) Key() string {
+	return fmt.Sprintf("%s|%s|%s", strings.Join(q.GroupBy, ","),
+		q.DateFrom.UTC().Format(time.RFC3339Nano), q.DateTo.UTC().Format(time.RFC3339Nano))
+}
+
+// StatsRow — итоги одной группы. Group содержит значения измерений по их названиям,
+// день — в формате YYYY-MM-DD (UTC). Суммы в минимальных единицах валюты, как в payment.
+type StatsRow struct {
+	Group        map[string]string `json:"group"`
+	Orders       int64             `json:"orders"`
+	Amount       int64             `json:"amount"`
+	DeliveryCost int64             `json:"delivery_cost"`
+	CustomFee    int64             `json:"custom_fee"`
+	// AvgAmount — средний чек (amount / orders)
+	AvgAmount float64 `json:"avg_amount"`
+}
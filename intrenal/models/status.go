@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type OrderStatus string
+
+const (
+	StatusCreated    OrderStatus = "created"
+	StatusPaid       OrderStatus = "paid"
+	StatusAssembling OrderStatus = "assembling"
+	StatusShipped    OrderStatus = "shipped"
+	StatusDelivered  OrderStatus = "delivered"
+	StatusCancelled  OrderStatus = "cancelled"
+	StatusReturned   OrderStatus = "returned"
+)
+
+// источники смены статуса для истории
+const (
+	StatusSourceHTTP  = "http"
+	StatusSourceKafka = "kafka"
+)
+
+var (
+	ErrUnknownStatus = errors.New("unknown order status")
+	// ErrInvalidTransition — переход между статусами запрещён
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
+
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	switch status := OrderStatus(s); status {
+	case StatusCreated, StatusPaid, StatusAssembling, StatusShipped, StatusDelivered, StatusCancelled, StatusReturned:
+		return status, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownStatus, s)
+	}
+}
+
+// StatusChange — запись order_status_history. From == To означает, что заказ уже был в этом статусе.
+// Version — версия заказа после перехода.
+type StatusChange struct {
+	OrderUID  string      `json:"order_uid"`
+	From      OrderStatus `json:"from"`
+	To        OrderStatus `json:"to"`
+	Source    string      `json:"source"`
+	Version   int64       `json:"version"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
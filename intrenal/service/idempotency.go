@@ -0,0 +1,71 @@
+package service
+
+import (
+	"WB_Service/intrenal/db"
+	"WB_Service/intrenal/lib/sl"
+	model "WB_Service/intrenal/models"
+	"context"
+	"log/slog"
+	"time"
+)
+
+type IdempotencyConfig struct {
+	TTL             time.Duration `yaml:"ttl" env-default:"24h"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"10m"`
+	// LockTimeout — сколько ключ без ответа считается занятым; потом его может забрать повторный запрос
+	LockTimeout time.Duration `yaml:"lock_timeout" env-default:"30s"`
+}
+
+// Idempotency хранит ответы на запросы с Idempotency-Key в Postgres
+type Idempotency struct {
+	db  *db.Postgres
+	cfg IdempotencyConfig
+	log *slog.Logger
+}
+
+func NewIdempotency(db *db.Postgres, cfg IdempotencyConfig, log *slog.Logger) *Idempotency {
+	return &Idempotency{
+		db:  db,
+		cfg: cfg,
+		log: log,
+	}
+}
+
+func (i *Idempotency) Reserve(ctx context.Context, key, requestHash string) (*model.IdempotencyRecord, error) {
+	return i.db.ReserveIdempotencyKey(ctx, key, requestHash, i.cfg.TTL, i.cfg.LockTimeout)
+}
+
+func (i *Idempotency) Complete(ctx context.Context, key string, response []byte) error {
+	return i.db.SaveIdempotencyResponse(ctx, key, response)
+}
+
+// Release освобождает ключ, если запрос не удался, чтобы клиент мог повторить его
+func (i *Idempotency) Release(ctx context.Context, key string) error {
+	return i.db.DeleteIdempotencyKey(ctx, key)
+}
+
+// RunCleanup периодически удаляет истёкшие ключи до отмены ctx
+func (i *Idempotency) RunCleanup(ctx context.Context) {
+	if i.cfg.CleanupInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(i.cfg.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n, err := i.db.DeleteExpiredIdempotencyKeys(ctx)
+			if err != nil {
+				i.log.Error("Error deleting expired idempotency keys", sl.Err(err))
+				continue
+			}
+			if n > 0 {
+				i.log.Info("Deleted expired idempotency keys", slog.Int64("count", n))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	model "WB_Service/intrenal/models"
+	"context"
+)
+
+func (s *Service) OrderRevisions(ctx context.Context, orderUID string) ([]model.OrderRevision, error) {
+	return s.db.GetOrderRevisions(ctx, orderUID)
+}
+
+// DiffOrderRevisions сравнивает ревизию from с ревизией to того же заказа
+func (s *Service) DiffOrderRevisions(ctx context.Context, orderUID string, from, to int) ([]model.FieldChange, error) {
+	a, err := s.db.GetOrderRevision(ctx, orderUID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.db.GetOrderRevision(ctx, orderUID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.DiffOrders(a.Order, b.Order), nil
+}
@@ -7,26 +7,34 @@ import (
 	model "WB_Service/intrenal/models"
 	"context"
 	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Service struct {
 	db    *db.Postgres
 	cache *cache.Cache
-	log   *slog.Logger
+	// outOfOrder — как поступать с устаревшими версиями заказа при сохранении
+	outOfOrder model.OutOfOrderPolicy
+	log        *slog.Logger
 }
 
-func NewService(db *db.Postgres, cache *cache.Cache, log *slog.Logger) *Service {
+func NewService(db *db.Postgres, cache *cache.Cache, outOfOrder model.OutOfOrderPolicy, log *slog.Logger) *Service {
 	return &Service{
-		db:    db,
-		cache: cache,
-		log:   log,
+		db:         db,
+		cache:      cache,
+		outOfOrder: outOfOrder,
+		log:        log,
 	}
 }
 
 func (s *Service) SaveOrder(ctx context.Context, order *model.Order) error {
 	// сохраняем в БД
-	if err := s.db.SaveUserData(ctx, order); err != nil {
-		s.log.Error("Error saving order", sl.Err(err))
+	if err := s.db.SaveUserData(ctx, order, s.outOfOrder); err != nil {
+		if !db.IsStaleWrite(err) {
+			s.log.Error("Error saving order", sl.Err(err))
+		}
 		return err
 	}
 
@@ -37,6 +45,27 @@ func (s *Service) SaveOrder(ctx context.Context, order *model.Order) error {
 	return nil
 }
 
+// SaveOrders сохраняет пачку заказов одной транзакцией, errs — ошибки по отдельным заказам
+func (s *Service) SaveOrders(ctx context.Context, orders []*model.Order) ([]error, error) {
+	errs, err := s.db.SaveUserDataBatch(ctx, orders, s.outOfOrder)
+	if err != nil {
+		s.log.Error("Error saving orders batch", sl.Err(err))
+		return nil, err
+	}
+
+	failed := 0
+	for i, order := range orders {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		s.cache.SetOrder(order)
+	}
+
+	s.log.Info("Save orders batch", slog.Int("saved", len(orders)-failed), slog.Int("failed", failed))
+	return errs, nil
+}
+
 func (s *Service) GetOrder(ctx context.Context, orderUID string) (*model.Order, error) {
 	// сначала берем из кеша
 	if order, found := s.cache.GetOrder(orderUID); found {
@@ -59,37 +88,169 @@ func (s *Service) GetOrder(ctx context.Context, orderUID string) (*model.Order,
 	return order, nil
 }
 
-func (s *Service) GetOrders(ctx context.Context) (map[string]*model.Order, error) {
-	// сначала пробуем из кеша
-	if orders, found := s.cache.GetAll(); found {
-		s.log.Info("Get all orders from cache")
-		return orders, nil
-	}
+// OrderVersion возвращает текущую версию заказа из БД: кеш может отставать
+func (s *Service) OrderVersion(ctx context.Context, orderUID string) (int64, error) {
+	return s.db.GetOrderVersion(ctx, orderUID)
+}
 
-	// если в кеше нет — идём в БД
+// GetOrders читает все заказы из БД. Кеш ограничен по размеру и хранит лишь часть заказов,
+// поэтому ни отдавать из него полный список, ни заливать в него весь результат нельзя.
+func (s *Service) GetOrders(ctx context.Context) (map[string]*model.Order, error) {
 	orders, err := s.db.GetOrders(ctx)
 	if err != nil {
 		s.log.Error("Error getting orders from DB", sl.Err(err))
 		return nil, err
 	}
 
-	// восстанавливаем кеш из БД
-	s.cache.ReStoreCache(orders)
+	s.log.Info("Get all orders from DB")
+	return orders, nil
+}
+
+// SearchOrders ищет заказы по ключам поддержки (трек-номер, покупатель, контакты, товар)
+func (s *Service) SearchOrders(ctx context.Context, search model.OrderSearch) ([]*model.Order, error) {
+	orders, err := s.db.SearchOrders(ctx, search)
+	if err != nil {
+		s.log.Error("Error searching orders", sl.Err(err))
+		return nil, err
+	}
 
-	s.log.Info("Get all orders from DB and restored cache")
 	return orders, nil
 }
 
-func (s *Service) RestoreCache(ctx context.Context) error {
-	// получаем все значения из базы данных
-	orders, err := s.db.GetOrders(ctx)
+// ImportOrders сохраняет пачку заказов из файла импорта и убирает их старые версии из кеша.
+// stale — заказы, не записанные по той же policy, что и при обычном сохранении.
+func (s *Service) ImportOrders(ctx context.Context, orders []*model.Order) ([]string, error) {
+	stale, err := s.db.ImportOrders(ctx, orders, s.outOfOrder)
 	if err != nil {
-		s.log.Error("Error get orders", sl.Err(err))
-		return err
+		s.log.Error("Error importing orders", sl.Err(err))
+		return nil, err
 	}
 
-	s.cache.ReStoreCache(orders)
-	s.log.Info("Restore cache successfully")
+	s.InvalidateOrders(orderUIDs(orders))
+	return stale, nil
+}
 
-	return nil
+// InvalidateOrders убирает заказы из кеша: их изменили в БД в обход сервиса (например, cmd/import)
+func (s *Service) InvalidateOrders(orderUIDs []string) {
+	for _, uid := range orderUIDs {
+		s.cache.Delete(uid)
+	}
+}
+
+func orderUIDs(orders []*model.Order) []string {
+	uids := make([]string, len(orders))
+	for i, order := range orders {
+		uids[i] = order.OrderUUID
+	}
+	return uids
+}
+
+// ExportOrders передаёт в fn все заказы под фильтр потоком из БД, минуя кеш
+func (s *Service) ExportOrders(ctx context.Context, filter model.OrderFilter, fn func(*model.Order) error) error {
+	return s.db.ExportOrders(ctx, filter, fn)
+}
+
+// ListOrders отдаёт страницу заказов из БД: keyset-пагинацию и фильтры кеш не поддерживает
+func (s *Service) ListOrders(ctx context.Context, filter model.OrderFilter) (*model.OrderPage, error) {
+	page, err := s.db.ListOrders(ctx, filter)
+	if err != nil {
+		s.log.Error("Error listing orders", sl.Err(err))
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// RestoreCache прогревает кеш свежими заказами из БД.
+// order_uid читаются потоково, а сами заказы загружаются пачками в несколько воркеров.
+func (s *Service) RestoreCache(ctx context.Context, cfg cache.WarmupConfig) (int, error) {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var since time.Time
+	if cfg.MaxAge > 0 {
+		since = time.Now().Add(-cfg.MaxAge)
+	}
+
+	started := time.Now()
+	batches := make(chan []string)
+	var (
+		loaded  atomic.Int64
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		loadErr error
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for uids := range batches {
+				n, err := s.warmBatch(ctx, uids)
+				if err != nil {
+					errOnce.Do(func() {
+						loadErr = err
+						cancel()
+					})
+					return
+				}
+
+				total := loaded.Add(int64(n))
+				s.log.Info("Cache warm-up progress",
+					slog.Int64("loaded", total),
+					slog.Duration("elapsed", time.Since(started)),
+				)
+			}
+		}()
+	}
+
+	scanErr := s.db.ScanRecentOrderUIDs(ctx, since, cfg.MaxCount, cfg.BatchSize, func(uids []string) error {
+		select {
+		case batches <- uids:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(batches)
+	wg.Wait()
+
+	total := int(loaded.Load())
+	if loadErr != nil {
+		scanErr = loadErr
+	}
+	if scanErr != nil {
+		s.log.Error("Cache warm-up interrupted", slog.Int("loaded", total), sl.Err(scanErr))
+		return total, scanErr
+	}
+
+	s.log.Info("Restore cache successfully",
+		slog.Int("loaded", total),
+		slog.Duration("elapsed", time.Since(started)),
+	)
+	return total, nil
+}
+
+// warmBatch загружает пачку заказов в кеш. Пока пачка читалась, consumer мог записать
+// более новую версию заказа в кеш — её не перезаписываем.
+func (s *Service) warmBatch(ctx context.Context, uids []string) (int, error) {
+	orders, err := s.db.GetOrdersByUIDs(ctx, uids)
+	if err != nil {
+		return 0, err
+	}
+	for _, order := range orders {
+		s.cache.SetOrderIfNewer(order)
+	}
+	return len(orders), nil
 }
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"WB_Service/intrenal/db"
+	"WB_Service/intrenal/lib/sl"
+	model "WB_Service/intrenal/models"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type StatsConfig struct {
+	// CacheTTL — сколько отдавать посчитанную статистику без повторного запроса в БД, 0 — не кешировать
+	CacheTTL time.Duration `yaml:"cache_ttl" env-default:"1m"`
+	// CacheSize — сколько разных запросов держать в кеше; при переполнении вытесняется ближайший к истечению
+	CacheSize int `yaml:"cache_size" env-default:"1000"`
+}
+
+type statsEntry struct {
+	rows      []model.StatsRow
+	expiresAt time.Time
+}
+
+// Stats считает статистику заказов и ненадолго запоминает результат по параметрам запроса
+type Stats struct {
+	db  *db.Postgres
+	cfg StatsConfig
+	log *slog.Logger
+
+	mu      sync.Mutex
+	entries map[string]statsEntry
+}
+
+func NewStats(db *db.Postgres, cfg StatsConfig, log *slog.Logger) *Stats {
+	return &Stats{
+		db:      db,
+		cfg:     cfg,
+		log:     log,
+		entries: make(map[string]statsEntry),
+	}
+}
+
+func (s *Stats) OrderStats(ctx context.Context, q model.StatsQuery) ([]model.StatsRow, error) {
+	key := q.Key()
+
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.rows, nil
+	}
+
+	rows, err := s.db.OrderStats(ctx, q)
+	if err != nil {
+		s.log.Error("Error getting order stats", sl.Err(err))
+		return nil, err
+	}
+
+	if s.cfg.CacheTTL > 0 {
+		now := time.Now()
+
+		s.mu.Lock()
+		// устаревшие записи убираем здесь же, отдельный janitor для короткого TTL не нужен
+		for k, e := range s.entries {
+			if now.After(e.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		if _, ok := s.entries[key]; !ok && s.cfg.CacheSize > 0 && len(s.entries) >= s.cfg.CacheSize {
+			s.evictOldest()
+		}
+		s.entries[key] = statsEntry{rows: rows, expiresAt: now.Add(s.cfg.CacheTTL)}
+		s.mu.Unlock()
+	}
+
+	return rows, nil
+}
+
+// evictOldest удаляет запись, которая истекает раньше остальных; вызывается под mu
+func (s *Stats) evictOldest() {
+	var oldest string
+	var oldestAt time.Time
+	for k, e := range s.entries {
+		if oldestAt.IsZero() || e.expiresAt.Before(oldestAt) {
+			oldest, oldestAt = k, e.expiresAt
+		}
+	}
+	delete(s.entries, oldest)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"WB_Service/intrenal/db"
+	"WB_Service/intrenal/lib/sl"
+	model "WB_Service/intrenal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// transitions — разрешённые переходы статуса заказа.
+// cancelled и returned — конечные статусы.
+var transitions = map[model.OrderStatus][]model.OrderStatus{
+	model.StatusCreated:    {model.StatusPaid, model.StatusCancelled},
+	model.StatusPaid:       {model.StatusAssembling, model.StatusCancelled},
+	model.StatusAssembling: {model.StatusShipped, model.StatusCancelled},
+	model.StatusShipped:    {model.StatusDelivered, model.StatusReturned},
+	model.StatusDelivered:  {model.StatusReturned},
+}
+
+func checkTransition(from, to model.OrderStatus) error {
+	if !slices.Contains(transitions[from], to) {
+		return fmt.Errorf("%w: %s -> %s", model.ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// ChangeOrderStatus переводит заказ в новый статус, если переход разрешён.
+// Повторная установка текущего статуса не ошибка: событие из Kafka может прийти дважды.
+// expectedVersion, если не 0, — версия, которую видел клиент (If-Match).
+func (s *Service) ChangeOrderStatus(ctx context.Context, orderUID string, to model.OrderStatus, source string, expectedVersion int64) (*model.StatusChange, error) {
+	change, err := s.db.UpdateOrderStatus(ctx, orderUID, to, source, expectedVersion, checkTransition)
+	if err != nil {
+		if !errors.Is(err, model.ErrInvalidTransition) && !errors.Is(err, db.ErrOrderNotFound) && !errors.Is(err, db.ErrVersionConflict) {
+			s.log.Error("Error changing order status", sl.Err(err))
+		}
+		return nil, err
+	}
+
+	if change.From != change.To {
+		// в кеше заказ со старым статусом
+		s.cache.Delete(orderUID)
+		s.log.Info("Order status changed",
+			slog.String("order_uid", orderUID),
+			slog.String("from", string(change.From)),
+			slog.String("to", string(change.To)),
+			slog.String("source", source),
+		)
+	}
+
+	return change, nil
+}
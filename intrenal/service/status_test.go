@@ -0,0 +1,53 @@
+package service
+
+import (
+	model "WB_Service/intrenal/models"
+	"errors"
+	"testing"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to model.OrderStatus
+		ok       bool
+	}{
+		{model.StatusCreated, model.StatusPaid, true},
+		{model.StatusCreated, model.StatusCancelled, true},
+		{model.StatusCreated, model.StatusAssembling, false},
+		{model.StatusCreated, model.StatusDelivered, false},
+		{model.StatusPaid, model.StatusAssembling, true},
+		{model.StatusPaid, model.StatusCancelled, true},
+		{model.StatusPaid, model.StatusCreated, false},
+		{model.StatusAssembling, model.StatusShipped, true},
+		{model.StatusAssembling, model.StatusCancelled, true},
+		{model.StatusAssembling, model.StatusReturned, false},
+		{model.StatusShipped, model.StatusDelivered, true},
+		{model.StatusShipped, model.StatusReturned, true},
+		{model.StatusShipped, model.StatusCancelled, false},
+		{model.StatusDelivered, model.StatusReturned, true},
+		{model.StatusDelivered, model.StatusCancelled, false},
+		{model.StatusCancelled, model.StatusPaid, false},
+		{model.StatusCancelled, model.StatusCreated, false},
+		{model.StatusReturned, model.StatusDelivered, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			err := checkTransition(tt.from, tt.to)
+			if tt.ok && err != nil {
+				t.Errorf("checkTransition() = %v, want nil", err)
+			}
+			if !tt.ok && !errors.Is(err, model.ErrInvalidTransition) {
+				t.Errorf("checkTransition() = %v, want ErrInvalidTransition", err)
+			}
+		})
+	}
+}
+
+func TestTerminalStatuses(t *testing.T) {
+	for _, status := range []model.OrderStatus{model.StatusCancelled, model.StatusReturned} {
+		if next := transitions[status]; len(next) > 0 {
+			t.Errorf("%s is terminal, but has transitions %v", status, next)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package validation
+
+import (
+	model "WB_Service/intrenal/models"
+	"fmt"
+	"time"
+)
+
+type Severity string
+
+const (
+	SeverityOff    Severity = "off"
+	SeverityWarn   Severity = "warn"
+	SeverityReject Severity = "reject"
+)
+
+// Имена правил согласованности заказа, используются в конфиге
+const (
+	RuleGoodsTotal      = "goods_total"
+	RuleAmount          = "amount"
+	RuleItemTrackNumber = "item_track_number"
+	RuleItemTotalPrice  = "item_total_price"
+	RuleDateNotFuture   = "date_not_future"
+	RuleUniqueRid       = "unique_rid"
+)
+
+// допустимое расхождение часов для date_created из будущего
+const clockSkew = 5 * time.Minute
+
+// Config задаёт строгость каждого правила; не указанные правила берут значение по умолчанию
+type Config struct {
+	Rules map[string]Severity `yaml:"rules"`
+}
+
+// Rule — проверка согласованности полей заказа.
+// Новые правила добавляются со строгостью warn; ужесточать до reject — явно в конфиге.
+type Rule struct {
+	Name     string
+	Severity Severity
+	Check    func(order *model.Order) []FieldError
+}
+
+var defaultRules = []Rule{
+	{Name: RuleGoodsTotal, Severity: SeverityWarn, Check: checkGoodsTotal},
+	{Name: RuleAmount, Severity: SeverityWarn, Check: checkAmount},
+	{Name: RuleItemTrackNumber, Severity: SeverityWarn, Check: checkItemTrackNumber},
+	{Name: RuleItemTotalPrice, Severity: SeverityWarn, Check: checkItemTotalPrice},
+	{Name: RuleDateNotFuture, Severity: SeverityWarn, Check: checkDateNotFuture},
+	{Name: RuleUniqueRid, Severity: SeverityWarn, Check: checkUniqueRid},
+}
+
+// payment.goods_total = сумма items[].total_price
+func checkGoodsTotal(order *model.Order) []FieldError {
+	sum := 0
+	for _, item := range order.Items {
+		sum += item.TotalPrice
+	}
+	if order.Payment.GoodsTotal == sum {
+		return nil
+	}
+	return []FieldError{{
+		Field:   "payment.goods_total",
+		Rule:    RuleGoodsTotal,
+		Param:   fmt.Sprint(sum),
+		Message: fmt.Sprintf("goods_total %d does not match sum of items total_price %d", order.Payment.GoodsTotal, sum),
+	}}
+}
+
+// payment.amount = goods_total + delivery_cost + custom_fee
+func checkAmount(order *model.Order) []FieldError {
+	p := order.Payment
+	expected := p.GoodsTotal + p.DeliveryCost + p.CustomFee
+	if p.Amount == expected {
+		return nil
+	}
+	return []FieldError{{
+		Field:   "payment.amount",
+		Rule:    RuleAmount,
+		Param:   fmt.Sprint(expected),
+		Message: fmt.Sprintf("amount %d does not match goods_total + delivery_cost + custom_fee = %d", p.Amount, expected),
+	}}
+}
+
+// items[].track_number совпадает с track_number заказа
+func checkItemTrackNumber(order *model.Order) []FieldError {
+	var errs []FieldError
+	for i, item := range order.Items {
+		if item.TrackNumber != order.TrackNumber {
+			errs = append(errs, FieldError{
+				Field:   fmt.Sprintf("items[%d].track_number", i),
+				Rule:    RuleItemTrackNumber,
+				Param:   order.TrackNumber,
+				Message: fmt.Sprintf("track_number %q differs from order track_number %q", item.TrackNumber, order.TrackNumber),
+			})
+		}
+	}
+	return errs
+}
+
+// items[].total_price = price со скидкой sale%, с точностью до округления
+func checkItemTotalPrice(order *model.Order) []FieldError {
+	var errs []FieldError
+	for i, item := range order.Items {
+		expected := item.Price * (100 - item.Sale) / 100
+		if diff := item.TotalPrice - expected; diff >= -1 && diff <= 1 {
+			continue
+		}
+		errs = append(errs, FieldError{
+			Field:   fmt.Sprintf("items[%d].total_price", i),
+			Rule:    RuleItemTotalPrice,
+			Param:   fmt.Sprint(expected),
+			Message: fmt.Sprintf("total_price %d is inconsistent with price %d and sale %d%%", item.TotalPrice, item.Price, item.Sale),
+		})
+	}
+	return errs
+}
+
+// date_created не позже текущего времени с учётом расхождения часов
+func checkDateNotFuture(order *model.Order) []FieldError {
+	if !order.DateCreated.After(time.Now().Add(clockSkew)) {
+		return nil
+	}
+	return []FieldError{{
+		Field:   "date_created",
+		Rule:    RuleDateNotFuture,
+		Message: "date_created is in the future",
+	}}
+}
+
+// items[].rid не повторяются внутри заказа
+func checkUniqueRid(order *model.Order) []FieldError {
+	var errs []FieldError
+	seen := make(map[string]int, len(order.Items))
+	for i, item := range order.Items {
+		if item.Rid == "" {
+			continue
+		}
+		if j, ok := seen[item.Rid]; ok {
+			errs = append(errs, FieldError{
+				Field:   fmt.Sprintf("items[%d].rid", i),
+				Rule:    RuleUniqueRid,
+				Param:   fmt.Sprintf("items[%d].rid", j),
+				Message: fmt.Sprintf("duplicates items[%d].rid", j),
+			})
+			continue
+		}
+		seen[item.Rid] = i
+	}
+	return errs
+}
@@ -0,0 +1,116 @@
+package validation
+
+import (
+	model "WB_Service/intrenal/models"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+// FieldError — ошибка одного поля в терминах JSON заказа
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error — заказ не прошёл проверку; содержит все найденные ошибки полей
+type Error struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *Error) add(field, rule, param, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Rule: rule, Param: param, Message: message})
+}
+
+// AsError достаёт *Error из цепочки ошибок
+func AsError(err error) (*Error, bool) {
+	var vErr *Error
+	ok := errors.As(err, &vErr)
+	return vErr, ok
+}
+
+// ValidateOrder проверяет теги validate заказа.
+// Правила согласованности с настраиваемой строгостью проверяет Validator.
+func ValidateOrder(order *model.Order) error {
+	vErr := &Error{}
+
+	// проверка на nil
+	if order.Delivery == (model.Delivery{}) {
+		vErr.add("delivery", "required", "", "delivery is empty")
+	}
+	if order.Payment == (model.Payment{}) {
+		vErr.add("payment", "required", "", "payment is empty")
+	}
+
+	if err := validate.Struct(order); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return err
+		}
+		for _, fe := range fieldErrs {
+			vErr.add(fieldName(fe), fe.Tag(), fe.Param(), message(fe))
+		}
+	}
+
+	if len(vErr.Fields) > 0 {
+		return vErr
+	}
+	return nil
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// в ошибках используем имена полей из JSON
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// fieldName превращает Order.delivery.phone в delivery.phone
+func fieldName(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email"
+	case "e164":
+		return "must be a phone number in E.164 format"
+	case "len":
+		return "must have length " + fe.Param()
+	case "min":
+		return "must have at least " + fe.Param() + " elements"
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	default:
+		return "failed on " + fe.Tag()
+	}
+}
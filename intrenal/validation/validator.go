@@ -0,0 +1,70 @@
+package validation
+
+import (
+	model "WB_Service/intrenal/models"
+	"fmt"
+)
+
+// Validator — структурная проверка заказа плюс правила согласованности с настраиваемой строгостью
+type Validator struct {
+	rules []Rule
+}
+
+func New(cfg Config) (*Validator, error) {
+	known := make(map[string]bool, len(defaultRules))
+	rules := make([]Rule, 0, len(defaultRules))
+
+	for _, rule := range defaultRules {
+		known[rule.Name] = true
+		if severity, ok := cfg.Rules[rule.Name]; ok {
+			rule.Severity = severity
+		}
+
+		switch rule.Severity {
+		case SeverityOff:
+			continue
+		case SeverityWarn, SeverityReject:
+			rules = append(rules, rule)
+		default:
+			return nil, fmt.Errorf("rule %s: unknown severity %q", rule.Name, rule.Severity)
+		}
+	}
+
+	for name := range cfg.Rules {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown validation rule %q", name)
+		}
+	}
+
+	return &Validator{
+		rules: rules,
+	}, nil
+}
+
+// Validate возвращает предупреждения по правилам со строгостью warn
+// и *Error, если нарушены теги validate или правила со строгостью reject
+func (v *Validator) Validate(order *model.Order) ([]FieldError, error) {
+	vErr := &Error{}
+	if err := ValidateOrder(order); err != nil {
+		e, ok := AsError(err)
+		if !ok {
+			return nil, err
+		}
+		vErr = e
+	}
+
+	var warnings []FieldError
+	for _, rule := range v.rules {
+		violations := rule.Check(order)
+		if rule.Severity == SeverityReject {
+			vErr.Fields = append(vErr.Fields, violations...)
+		} else {
+			warnings = append(warnings, violations...)
+		}
+	}
+
+	if len(vErr.Fields) > 0 {
+		return warnings, vErr
+	}
+	return warnings, nil
+}
@@ -0,0 +1,193 @@
+package validation
+
+import (
+	model "WB_Service/intrenal/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func validOrder() *model.Order {
+	return &model.Order{
+		OrderUUID:   "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: model.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Email:   "test@gmail.com",
+		},
+		Payment: model.Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []model.Item{{
+			ChrtID:      9934930,
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       453,
+			Rid:         "ab4219087a764ae0btest",
+			Name:        "Mascaras",
+			Sale:        30,
+			TotalPrice:  317,
+			NmID:        2389212,
+			Brand:       "Vivienne Sabo",
+		}},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		DateCreated:     time.Now().Add(-time.Hour),
+	}
+}
+
+func rules(fields []FieldError) []string {
+	res := make([]string, 0, len(fields))
+	for _, f := range fields {
+		res = append(res, f.Rule)
+	}
+	return res
+}
+
+func TestNewDefaultsToWarn(t *testing.T) {
+	for _, rule := range defaultRules {
+		if rule.Severity != SeverityWarn {
+			t.Errorf("rule %s: default severity %q, want %q", rule.Name, rule.Severity, SeverityWarn)
+		}
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules map[string]Severity
+	}{
+		{name: "unknown rule", rules: map[string]Severity{"no_such_rule": SeverityWarn}},
+		{name: "unknown severity", rules: map[string]Severity{RuleAmount: "fatal"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(Config{Rules: tt.rules}); err == nil {
+				t.Error("New() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		rules        map[string]Severity
+		modify       func(o *model.Order)
+		wantWarnings []string
+		wantErrors   []string
+	}{
+		{
+			name:   "valid order",
+			modify: func(o *model.Order) {},
+		},
+		{
+			name:       "struct tags always reject",
+			modify:     func(o *model.Order) { o.Delivery.Email = "not-an-email" },
+			wantErrors: []string{"email"},
+		},
+		{
+			name:         "goods_total mismatch warns",
+			modify:       func(o *model.Order) { o.Payment.GoodsTotal = 300 },
+			wantWarnings: []string{RuleGoodsTotal, RuleAmount},
+		},
+		{
+			name:         "amount mismatch warns",
+			modify:       func(o *model.Order) { o.Payment.Amount = 100 },
+			wantWarnings: []string{RuleAmount},
+		},
+		{
+			name:       "amount mismatch rejects when configured",
+			rules:      map[string]Severity{RuleAmount: SeverityReject},
+			modify:     func(o *model.Order) { o.Payment.Amount = 100 },
+			wantErrors: []string{RuleAmount},
+		},
+		{
+			name:   "amount mismatch ignored when off",
+			rules:  map[string]Severity{RuleAmount: SeverityOff},
+			modify: func(o *model.Order) { o.Payment.Amount = 100 },
+		},
+		{
+			name:         "item track_number warns",
+			modify:       func(o *model.Order) { o.Items[0].TrackNumber = "OTHER" },
+			wantWarnings: []string{RuleItemTrackNumber},
+		},
+		{
+			name:   "item total_price rounding tolerated",
+			modify: func(o *model.Order) { o.Items[0].TotalPrice = 318; o.Payment.GoodsTotal = 318; o.Payment.Amount = 1818 },
+		},
+		{
+			name:         "item total_price warns",
+			modify:       func(o *model.Order) { o.Items[0].TotalPrice = 400; o.Payment.GoodsTotal = 400; o.Payment.Amount = 1900 },
+			wantWarnings: []string{RuleItemTotalPrice},
+		},
+		{
+			name:         "date_created in the future warns",
+			modify:       func(o *model.Order) { o.DateCreated = time.Now().Add(time.Hour) },
+			wantWarnings: []string{RuleDateNotFuture},
+		},
+		{
+			name:   "date_created within clock skew",
+			modify: func(o *model.Order) { o.DateCreated = time.Now().Add(clockSkew / 2) },
+		},
+		{
+			name: "duplicate rid warns",
+			modify: func(o *model.Order) {
+				o.Items = append(o.Items, o.Items[0])
+				o.Payment.GoodsTotal = 634
+				o.Payment.Amount = 2134
+			},
+			wantWarnings: []string{RuleUniqueRid},
+		},
+		{
+			name:  "duplicate rid rejects when configured",
+			rules: map[string]Severity{RuleUniqueRid: SeverityReject},
+			modify: func(o *model.Order) {
+				o.Items = append(o.Items, o.Items[0])
+				o.Payment.GoodsTotal = 634
+				o.Payment.Amount = 2134
+			},
+			wantErrors: []string{RuleUniqueRid},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := New(Config{Rules: tt.rules})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			order := validOrder()
+			tt.modify(order)
+
+			warnings, err := v.Validate(order)
+			if got := rules(warnings); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+
+			var gotErrors []string
+			if err != nil {
+				vErr, ok := AsError(err)
+				if !ok {
+					t.Fatalf("Validate error %v is not *Error", err)
+				}
+				gotErrors = rules(vErr.Fields)
+			}
+			if !slices.Equal(gotErrors, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", gotErrors, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -107,7 +107,7 @@ async function getOrders() {
             return;
         }
         const data = await res.json();
-        resultElem.innerHTML = renderOrdersList(data);
+        resultElem.innerHTML = renderOrdersList(data.orders);
     } catch (err) {
         resultElem.innerHTML = `<p>⚠️ Fetch error: ${err}</p>`;
     }